@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/blang/semver/v4"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strings"
+)
+
+var (
+	errMissingVersion = errors.New("version is required")
+	errNegativeCount  = errors.New("count can't be negative")
+)
+
+// DesiredRollout overrides the RolloutConfig for a single apply.
+type DesiredRollout struct {
+	// SkipFirstN overrides RolloutConfig.SkipFirstN when set.
+	SkipFirstN *int
+	// BatchSize overrides RolloutConfig.BatchSize when set.
+	BatchSize *int
+}
+
+// DesiredStateFile is the declarative description of the cluster used by the apply command.
+type DesiredStateFile struct {
+	// Group is the group to deploy to. If empty, carousel chooses the group.
+	Group string
+	// Count is the number of nodes of the group.
+	Count int
+	// Version is the version of the group.
+	Version string
+	// Rollout overrides the rolloutConfig of the config file.
+	Rollout DesiredRollout
+}
+
+// readDesiredState reads a DesiredStateFile in any format supported by viper, e.g. yaml or json.
+func readDesiredState(filename string) (DesiredStateFile, model.DesiredState, error) {
+	v := viper.New()
+	v.SetConfigFile(filename)
+	if err := v.ReadInConfig(); err != nil {
+		return DesiredStateFile{}, model.DesiredState{}, err
+	}
+	file := DesiredStateFile{}
+	if err := v.Unmarshal(&file); err != nil {
+		return DesiredStateFile{}, model.DesiredState{}, err
+	}
+
+	desired := model.DesiredState{
+		Count: file.Count,
+	}
+	if file.Count < 0 {
+		return file, desired, errNegativeCount
+	}
+	if file.Version == "" {
+		return file, desired, errMissingVersion
+	}
+	version, err := semver.Parse(file.Version)
+	if err != nil {
+		return file, desired, err
+	}
+	desired.Version = version
+	if file.Group != "" {
+		group, err := model.ParseColor(file.Group)
+		if err != nil {
+			return file, desired, err
+		}
+		desired.Group = group
+	}
+	return file, desired, nil
+}
+
+type ApplyCommand struct {
+	TransitionMeta
+	desiredFile string
+}
+
+func (c *ApplyCommand) Help() string {
+	helpText := `
+Usage: %s apply -f <desired_file> [options]
+
+ apply will transition the cluster to the state described in the desired file.
+ Nothing is done if the cluster already matches the desired state.
+
+ The desired file contains:
+
+  group: blue        # (Optional) the group to deploy to.
+  count: 4           # the number of nodes.
+  version: 1.2.3     # the version to deploy.
+  rollout:           # (Optional) overrides the rolloutConfig of the config file.
+    batchSize: 2
+    skipFirstN: 0
+
+Options:
+
+  -f, --filename  The desired state file.
+  --config        The configuration file to use. Overrides the search path.
+  --force         Apply the version even if the version policy refuses it.
+  --format        Output format of the progress and summary: table, json, yaml, csv or template=<go template>.
+  --safe          Plan each step and abort if the plan creates or destroys unexpected resources.
+`
+	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName))
+}
+
+func (c *ApplyCommand) Synopsis() string {
+	return "transition to the cluster state of a desired state file"
+}
+
+func (c *ApplyCommand) Run(args []string) int {
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.defaultFlagSet("apply")
+	cmdFlags.StringVar(&c.Meta.file, "config", "", "the configuration file to use.  Overrides the search path.")
+	cmdFlags.StringVarP(&c.desiredFile, "filename", "f", "", "the desired state file")
+	cmdFlags.BoolVar(&c.TransitionMeta.force, "force", false, "ignore the version policy")
+	c.TransitionMeta.addTransitionFlags(cmdFlags)
+	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.desiredFile == "" || cmdFlags.NArg() != 0 {
+		c.UI.Error("a desired state file must be provided")
+		c.UI.Error(c.Help())
+		return 1
+	}
+
+	carousel := c.TransitionMeta.getCarousel()
+	file, desired, err := readDesiredState(c.desiredFile)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to read desired state file %s: %v", c.desiredFile, err))
+		return 1
+	}
+
+	rollout := c.TransitionMeta.configRollout()
+	if file.Rollout.BatchSize != nil {
+		rollout.BatchSize = *file.Rollout.BatchSize
+	}
+	if file.Rollout.SkipFirstN != nil {
+		rollout.SkipFirstN = *file.Rollout.SkipFirstN
+	}
+
+	err = carousel.Apply(desired, c.TransitionMeta.stepOptions(rollout)...)
+	return c.handleExitError(err)
+}
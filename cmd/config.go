@@ -1,7 +1,11 @@
 package main
 
 import (
+	"github.com/xmidt-org/carousel/pkg/controller/exec"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
 	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/policy"
+	"strings"
 )
 
 // RolloutConfig specifies the options for transitioning the cluster to the new state.
@@ -12,12 +16,87 @@ type RolloutConfig struct {
 	// BatchSize configures how many nodes can be batched at once.
 	// If >1 then each step will change by no more than the value set.
 	BatchSize int
+	// Parallelism limits the concurrent operations of terraform in each step.
+	// (Optional): default 0, the terraform default.
+	Parallelism int
+	// Refresh is when the state is refreshed during a step: always, first or never.
+	// first only refreshes on the first step, which saves a refresh per step on a large state.
+	// (Optional): default always.
+	Refresh string
+	// LockTimeout is how long terraform waits for the state lock, e.g. 5m.
+	// (Optional): default is the terraform default.
+	LockTimeout string
+	// TargetGroups scopes the apply of each step to the modules of the groups it changes.
+	TargetGroups bool
 }
 
+// HistoryConfig specifies where the history of each rollout, resume and apply is recorded.
+type HistoryConfig struct {
+	// File is the JSON-lines file the history is appended to.
+	// (Optional): default .carousel/history.jsonl
+	File string
+	// Disable stops recording the history.
+	Disable bool
+	// Operator is recorded as the user running carousel. If empty, the current user is used.
+	Operator string
+}
+
+// OutputLogConfig specifies where the output of the commands run by each rollout, resume and apply is kept.
+type OutputLogConfig struct {
+	// Dir is the directory of the logs, each run gets a directory named by the time it started.
+	// (Optional): default .carousel/logs
+	Dir string
+	// Disable stops keeping the output of the commands.
+	Disable bool
+}
+
+// Controller names of the infrastructure as code tools carousel can drive.
+const (
+	terraformController = "terraform"
+	pulumiController    = "pulumi"
+	execController      = "exec"
+)
+
 // Config provides the configuration to the carousel binary.
 type Config struct {
+	// Controller is the infrastructure as code tool managing the cluster: terraform, pulumi or exec.
+	// With pulumi the workspace is the stack, the count and version variables are stack config set by pulumi up
+	// and the hostnames and version outputs are stack outputs. exec runs the commands of Exec.
+	// (Optional): default terraform
+	Controller string
+	// Exec configures the commands of the exec controller, for any other tooling.
+	Exec exec.Config
 	// Workspace the terraform workspace to use. If empty, the current workspace will be used.
-	Workspace     string
-	BinaryConfig  model.BinaryConfig
-	RolloutConfig RolloutConfig
+	Workspace string
+	// DisableWorkspaceCreation fails instead of creating the Workspace if it doesn't exist.
+	DisableWorkspaceCreation bool
+	BinaryConfig             model.BinaryConfig
+	RolloutConfig            RolloutConfig
+	// Groups are the deployment groups of the cluster, in rotation order. If empty, blue and green are used.
+	Groups []model.ColorGroup
+	// Naming configures the terraform variable and output names of each group.
+	Naming terraform.NamingConfig
+	// VersionPolicy configures which versions can be rolled out.
+	VersionPolicy policy.VersionConfig
+	// History configures the rollout history.
+	History HistoryConfig
+	// OutputLog configures the logs of the output of each command run by a rollout.
+	OutputLog OutputLogConfig
+	// HostReplacement is how the hosts failing validation are replaced: auto, taint or replace.
+	// replace passes the resources of the hosts as -replace to the next apply, which needs terraform 0.15.2 or newer.
+	// (Optional): default auto, replace if the terraform version supports it, otherwise taint.
+	HostReplacement string
+	// SafeMode plans each step and checks the plan before applying it, any resource created or destroyed outside
+	// of the groups changing count aborts the step. Also set with --safe.
+	SafeMode bool
+}
+
+// usesPulumi returns true if the cluster is managed by pulumi instead of terraform.
+func (c Config) usesPulumi() bool {
+	return strings.EqualFold(c.Controller, pulumiController)
+}
+
+// usesExec returns true if the cluster is managed by the commands of the Exec config.
+func (c Config) usesExec() bool {
+	return strings.EqualFold(c.Controller, execController)
 }
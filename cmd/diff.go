@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/diff"
+	"github.com/xmidt-org/carousel/pkg/goal"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/resume"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+type DiffCommand struct {
+	Meta
+}
+
+func (c *DiffCommand) Help() string {
+	helpText := `
+Usage: %s diff <snapshot_file> [options]
+       %s diff <count> <version> [options]
+
+  Compare the current cluster to a snapshot or a desired count and version.
+
+  With a snapshot the changes from the snapshot to the current cluster are shown,
+  including the hosts added and removed. The snapshot is written by state --save,
+  state --json --full, or is the original cluster of a step file.
+
+  With a count and version the changes from the current cluster to the desired
+  cluster are shown, as apply would build it.
+
+Options:
+
+  --group      The group to deploy the desired cluster to, if empty carousel chooses the group.
+  --json       Output the diff as a JSON object, the same as --format json.
+  --format     Output format: table, json, yaml, csv or template=<go template>.
+  --state-file Read the cluster from a local state file instead of running terraform state pull.
+`
+	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName, applicationName))
+}
+
+func (c *DiffCommand) Synopsis() string {
+	return "compare the cluster to a snapshot or desired state"
+}
+
+func (c *DiffCommand) Run(args []string) int {
+	var (
+		jsonOutput bool
+		group      string
+		format     string
+		stateFile  string
+	)
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.extendedFlagSet("diff")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json output")
+	cmdFlags.StringVar(&group, "group", "", "the group to deploy the desired cluster to")
+	cmdFlags.StringVar(&stateFile, "state-file", "", "state file to read the cluster from instead of pulling the state")
+	addFormatFlag(cmdFlags, &format)
+	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	if cmdFlags.NArg() != 1 && cmdFlags.NArg() != 2 {
+		c.UI.Error("one or two arguments must be provided")
+		c.UI.Error(c.Help())
+		return 1
+	}
+	formatter, structured, err := buildFormatter(format, jsonOutput)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	clusterGetter, err := c.Meta.clusterGetter(stateFile)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	cluster, err := clusterGetter.GetCluster()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to get Cluster state: \n %v", err))
+		return 1
+	}
+
+	var d diff.Diff
+	if cmdFlags.NArg() == 1 {
+		data, err := ioutil.ReadFile(cmdFlags.Arg(0))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("failed to read snapshot file %v", err))
+			return 1
+		}
+		snapshot, err := readSnapshot(data)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("failed to read snapshot file %v", err))
+			return 1
+		}
+		d = diff.Clusters(snapshot, cluster)
+	} else {
+		nodeCount, err := strconv.Atoi(cmdFlags.Arg(0))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to determine number of servers %v", err))
+			return 1
+		}
+		version, err := semver.Parse(cmdFlags.Arg(1))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to determine version of servers %v", err))
+			return 1
+		}
+		color := model.Unknown
+		if group != "" {
+			if color, err = model.ParseColor(group); err != nil {
+				c.UI.Error(fmt.Sprintf("Failed to determine group %v", err))
+				return 1
+			}
+		}
+		goalCluster, err := goal.BuildDesiredState(cluster.AsClusterState(), color, nodeCount, version)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to build desired cluster %v", err))
+			return 1
+		}
+		d = diff.States(cluster.AsClusterState(), goalCluster)
+	}
+
+	if structured {
+		result, err := formatter.Output(d, diffTable(d))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("\nError formatting output: %s", err))
+			return 1
+		}
+		c.UI.Output(result)
+		return 0
+	}
+	c.UI.Output(formatDiff(d))
+	return 0
+}
+
+// readSnapshot reads a model.Cluster json file, or the original cluster of a step file.
+func readSnapshot(data []byte) (model.Cluster, error) {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	if _, ok := keys["original_cluster"]; ok {
+		file, err := resume.Load(data)
+		if err != nil {
+			return nil, err
+		}
+		return file.OriginalCluster, nil
+	}
+	var cluster model.Cluster
+	if err := json.Unmarshal(data, &cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// formatDiff describes the changes of each Color Group, followed by the hosts added and removed.
+// A change reads blue: 0 -> 2 nodes, version 0.1.0 -> 0.2.0.
+func formatDiff(d diff.Diff) string {
+	if !d.Changed() {
+		return "no changes"
+	}
+	var lines []string
+	for _, group := range d {
+		if !group.Changed() {
+			lines = append(lines, fmt.Sprintf("%s: unchanged", group.Color))
+			continue
+		}
+		changes := []string{fmt.Sprintf("%d -> %d nodes", group.FromCount, group.ToCount)}
+		switch {
+		case group.VersionChanged():
+			changes = append(changes, fmt.Sprintf("version %s -> %s", group.FromVersion, group.ToVersion))
+		case group.ToCount > 0:
+			changes = append(changes, fmt.Sprintf("version %s", group.ToVersion))
+		case group.FromCount > 0:
+			changes = append(changes, fmt.Sprintf("version %s", group.FromVersion))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", group.Color, strings.Join(changes, ", ")))
+		for _, host := range group.AddedHosts {
+			lines = append(lines, fmt.Sprintf("\t+ %s", host))
+		}
+		for _, host := range group.RemovedHosts {
+			lines = append(lines, fmt.Sprintf("\t- %s", host))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller/exec"
+	"github.com/xmidt-org/carousel/pkg/controller/pulumi"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/policy"
+	"os"
+	osexec "os/exec"
+	"strings"
+)
+
+type DoctorCommand struct {
+	Meta
+}
+
+func (c *DoctorCommand) Help() string {
+	helpText := `
+Usage: %s doctor [options]
+
+  Check the config, the binary, the workspace and the terraform project before a rollout.
+
+  The .tf files of the working directory are parsed, each group must have its count and
+  version variables and its hostnames and version outputs. A module used by the outputs of
+  a group must be given the count and version variables of the group.
+  Nothing is changed, terraform init isn't run and the workspace isn't selected.
+
+Options:
+
+  -f, --file   The configuration file to use. Overrides the search path.
+`
+	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName))
+}
+
+func (c *DoctorCommand) Synopsis() string {
+	return "check the setup before a rollout"
+}
+
+// checkResult is the outcome of a single check of the doctor command.
+type checkResult struct {
+	name    string
+	failed  bool
+	warning bool
+	message string
+	details []string
+}
+
+func (c *DoctorCommand) Run(args []string) int {
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.extendedFlagSet("doctor")
+	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	c.Meta.reportConfigErrs = true
+	config := c.Meta.readConfig()
+
+	naming, namingErr := terraform.BuildNaming(config.Naming)
+	results := []checkResult{c.checkConfig(config, namingErr)}
+	binary := checkBinary(config)
+	results = append(results, binary, checkWorkingDirectory(config.BinaryConfig))
+	if binary.failed {
+		results = append(results, checkResult{name: "workspace", warning: true, message: "skipped, the binary can't be run"})
+	} else {
+		results = append(results, checkWorkspace(config))
+	}
+	if config.usesPulumi() || config.usesExec() {
+		results = append(results, checkResult{name: "project", warning: true, message: "skipped, only terraform projects are checked"})
+	} else if namingErr != nil {
+		results = append(results, checkResult{name: "project", warning: true, message: "skipped, the naming config is invalid"})
+	} else {
+		results = append(results, checkProject(config.BinaryConfig, naming))
+	}
+
+	failed := false
+	for _, result := range results {
+		line := fmt.Sprintf("%s: %s", result.name, result.message)
+		switch {
+		case result.failed:
+			failed = true
+			c.UI.Error("[fail] " + line)
+		case result.warning:
+			c.UI.Warn("[warn] " + line)
+		default:
+			c.UI.Info("[ok] " + line)
+		}
+		for _, detail := range result.details {
+			c.UI.Output("\t" + detail)
+		}
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func (c *DoctorCommand) checkConfig(config Config, namingErr error) checkResult {
+	result := checkResult{name: "config", message: "valid"}
+	for _, err := range c.Meta.configErrs {
+		result.details = append(result.details, err.Error())
+	}
+	if namingErr != nil {
+		result.details = append(result.details, fmt.Sprintf("naming: %v", namingErr))
+	}
+	if _, err := policy.NewVersionPolicy(config.VersionPolicy); err != nil {
+		result.details = append(result.details, fmt.Sprintf("versionPolicy: %v", err))
+	}
+	if _, err := stepTuning(config.RolloutConfig); err != nil {
+		result.details = append(result.details, fmt.Sprintf("rolloutConfig: %v", err))
+	}
+	if len(result.details) > 0 {
+		result.failed = true
+		result.message = "invalid"
+		return result
+	}
+	colors := make([]string, 0, len(model.ValidColors))
+	for _, color := range model.ValidColors {
+		colors = append(colors, color.String())
+	}
+	result.message = fmt.Sprintf("valid, groups %s", strings.Join(colors, ", "))
+	return result
+}
+
+func checkBinary(carouselConfig Config) checkResult {
+	if carouselConfig.usesExec() {
+		return checkExecCommands(carouselConfig.Exec)
+	}
+	config := carouselConfig.BinaryConfig
+	binary := config.Binary
+	if binary == "" {
+		binary = terraformController
+		if carouselConfig.usesPulumi() {
+			binary = pulumiController
+		}
+	}
+	result := checkResult{name: "binary"}
+	path, err := osexec.LookPath(binary)
+	if err != nil {
+		result.failed = true
+		result.message = err.Error()
+		return result
+	}
+	if carouselConfig.usesPulumi() {
+		version, err := pulumi.DetectVersion(config)
+		if err != nil {
+			result.failed = true
+			result.message = fmt.Sprintf("%s: %v", path, err)
+			return result
+		}
+		result.message = fmt.Sprintf("Pulumi %s (%s)", version, path)
+		return result
+	}
+	capabilities, err := terraform.DetectCapabilities(config)
+	if err != nil {
+		result.failed = true
+		result.message = fmt.Sprintf("%s: %v", path, err)
+		return result
+	}
+	result.message = fmt.Sprintf("%s (%s)", capabilities, path)
+	if !capabilities.Replace {
+		result.details = append(result.details, "apply -replace isn't supported, failed hosts are tainted")
+	}
+	if !capabilities.SelectOrCreate {
+		result.details = append(result.details, "workspace select -or-create isn't supported, workspace new is used")
+	}
+	return result
+}
+
+// checkExecCommands finds the binary of each configured exec command, a templated binary is only known when it runs.
+func checkExecCommands(config exec.Config) checkResult {
+	result := checkResult{name: "binary", message: "exec commands"}
+	commands := []struct {
+		name string
+		args []string
+	}{
+		{name: "getCluster", args: config.GetCluster},
+		{name: "applyStep", args: config.ApplyStep},
+		{name: "taintHost", args: config.TaintHost},
+		{name: "selectWorkspace", args: config.SelectWorkspace},
+	}
+	for _, command := range commands {
+		if len(command.args) == 0 || strings.Contains(command.args[0], "{{") {
+			continue
+		}
+		path, err := osexec.LookPath(command.args[0])
+		if err != nil {
+			result.failed = true
+			result.details = append(result.details, fmt.Sprintf("%s: %v", command.name, err))
+			continue
+		}
+		result.details = append(result.details, fmt.Sprintf("%s: %s", command.name, path))
+	}
+	return result
+}
+
+func checkWorkingDirectory(config model.BinaryConfig) checkResult {
+	result := checkResult{name: "working directory"}
+	if config.WorkingDirectory == "" {
+		result.message = "current directory"
+		return result
+	}
+	info, err := os.Stat(config.WorkingDirectory)
+	if err != nil || !info.IsDir() {
+		// the binary would silently run in the current directory instead.
+		result.failed = true
+		result.message = fmt.Sprintf("%s is not a directory", config.WorkingDirectory)
+		return result
+	}
+	result.message = config.WorkingDirectory
+	return result
+}
+
+func checkWorkspace(config Config) checkResult {
+	result := checkResult{name: "workspace"}
+	if config.usesExec() {
+		switch {
+		case config.Workspace == "":
+			result.message = "using the current workspace"
+		case len(config.Exec.SelectWorkspace) == 0:
+			result.failed = true
+			result.message = fmt.Sprintf("%s can't be selected without the selectWorkspace command", config.Workspace)
+		default:
+			result.message = fmt.Sprintf("%s, selected with the selectWorkspace command", config.Workspace)
+		}
+		return result
+	}
+	// terraform is checked without init, which could change the working directory.
+	currentWorkspace := func() (string, error) { return terraform.CurrentWorkspace(config.BinaryConfig) }
+	listWorkspaces := func() ([]string, error) { return terraform.ListWorkspaces(config.BinaryConfig) }
+	if config.usesPulumi() {
+		stacks := pulumi.BuildStackManager(config.BinaryConfig, false)
+		currentWorkspace, listWorkspaces = stacks.CurrentWorkspace, stacks.ListWorkspaces
+	}
+	if config.Workspace == "" {
+		current, err := currentWorkspace()
+		if err != nil {
+			result.failed = true
+			result.message = err.Error()
+			return result
+		}
+		result.message = fmt.Sprintf("using the current workspace %s", current)
+		return result
+	}
+	workspaces, err := listWorkspaces()
+	if err != nil {
+		result.failed = true
+		result.message = err.Error()
+		return result
+	}
+	for _, workspace := range workspaces {
+		if workspace == config.Workspace {
+			result.message = config.Workspace
+			return result
+		}
+	}
+	if config.DisableWorkspaceCreation {
+		result.failed = true
+		result.message = fmt.Sprintf("%s doesn't exist and workspace creation is disabled", config.Workspace)
+		return result
+	}
+	result.warning = true
+	result.message = fmt.Sprintf("%s doesn't exist, it will be created", config.Workspace)
+	return result
+}
+
+func checkProject(config model.BinaryConfig, naming terraform.Naming) checkResult {
+	result := checkResult{name: "project"}
+	problems, err := terraform.CheckProject(config.WorkingDirectory, naming)
+	if err != nil {
+		result.failed = true
+		result.message = err.Error()
+		return result
+	}
+	if len(problems) > 0 {
+		result.failed = true
+		result.message = fmt.Sprintf("%d problems with the variables and outputs of the groups", len(problems))
+		for _, problem := range problems {
+			result.details = append(result.details, problem.String())
+		}
+		return result
+	}
+	result.message = "every group has its variables and outputs"
+	return result
+}
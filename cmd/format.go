@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mitchellh/cli"
+	"github.com/spf13/pflag"
+	"github.com/xmidt-org/carousel/pkg/carousel"
+	"github.com/xmidt-org/carousel/pkg/diff"
+	"github.com/xmidt-org/carousel/pkg/history"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/output"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// addFormatFlag adds the --format flag, shared by the commands with structured output.
+func addFormatFlag(cmdFlags *pflag.FlagSet, format *string) {
+	cmdFlags.StringVar(format, "format", "", fmt.Sprintf("output format [%s]", strings.Join(output.Formats(), ", ")))
+}
+
+// buildFormatter builds the output.Formatter of the --format flag, --json is the same as --format json.
+// ok is false if neither is set, so the human readable output is used.
+func buildFormatter(format string, jsonOutput bool) (formatter output.Formatter, ok bool, err error) {
+	if format == "" && jsonOutput {
+		format = string(output.JSON)
+	}
+	if format == "" {
+		return output.Formatter{}, false, nil
+	}
+	formatter, err = output.NewFormatter(format)
+	return formatter, err == nil, err
+}
+
+// stderrUI writes everything to stderr, so stdout only has the formatted output.
+func stderrUI() cli.Ui {
+	return &cli.BasicUi{
+		Reader:      os.Stdin,
+		Writer:      os.Stderr,
+		ErrorWriter: os.Stderr,
+	}
+}
+
+// formatReporter outputs the progress and summary of a transition with an output.Stream.
+type formatReporter struct {
+	stream *output.Stream
+	ui     cli.Ui
+}
+
+func (f formatReporter) StepCompleted(progress carousel.StepProgress) {
+	row := []string{progress.Time.Format(time.RFC3339), string(progress.Operation), fmt.Sprintf("%d/%d", progress.Step, progress.Total)}
+	columns := []string{"TIME", "OPERATION", "STEP"}
+	for _, color := range model.ValidColors {
+		columns = append(columns, strings.ToUpper(color.String()))
+		row = append(row, strconv.Itoa(progress.State[color]))
+	}
+	if err := f.stream.Write(progress, output.Rows{Columns: columns, Values: [][]string{row}}); err != nil {
+		f.ui.Error(fmt.Sprintf("Failed to output progress: %v", err))
+	}
+}
+
+func (f formatReporter) Finished(summary history.Record) {
+	if err := f.stream.Write(summary, summaryTable(summary)); err != nil {
+		f.ui.Error(fmt.Sprintf("Failed to output summary: %v", err))
+	}
+}
+
+// clusterStateTable has a row for each Color Group.
+func clusterStateTable(state model.ClusterState) output.Rows {
+	rows := output.Rows{Columns: []string{"GROUP", "VERSION", "COUNT"}}
+	for _, color := range model.ValidColors {
+		rows.Values = append(rows.Values, []string{color.String(), state[color].Version.String(), strconv.Itoa(state[color].Count)})
+	}
+	return rows
+}
+
+// clusterTable has a row for each host, a Color Group without hosts has a single row without a host.
+func clusterTable(cluster model.Cluster) output.Rows {
+	rows := output.Rows{Columns: []string{"GROUP", "VERSION", "HOST", "IP", "ZONE", "ADDRESS"}}
+	for _, color := range model.ValidColors {
+		group := cluster[color]
+		if len(group.Hosts) == 0 {
+			rows.Values = append(rows.Values, []string{color.String(), group.Version.String(), "", "", "", ""})
+		}
+		for _, hostname := range group.Hosts {
+			host := group.Host(hostname)
+			rows.Values = append(rows.Values, []string{color.String(), group.Version.String(), host.FQDN, host.IP, host.Zone, host.Address})
+		}
+	}
+	return rows
+}
+
+// diffTable has a row for each Color Group, hosts are separated by spaces.
+func diffTable(d diff.Diff) output.Rows {
+	rows := output.Rows{Columns: []string{"GROUP", "FROM_COUNT", "TO_COUNT", "FROM_VERSION", "TO_VERSION", "ADDED", "REMOVED"}}
+	for _, group := range d {
+		rows.Values = append(rows.Values, []string{
+			group.Color.String(),
+			strconv.Itoa(group.FromCount),
+			strconv.Itoa(group.ToCount),
+			group.FromVersion.String(),
+			group.ToVersion.String(),
+			strings.Join(group.AddedHosts, " "),
+			strings.Join(group.RemovedHosts, " "),
+		})
+	}
+	return rows
+}
+
+// recordsTable has a row for each history.Record.
+func recordsTable(records []history.Record) output.Rows {
+	rows := output.Rows{Columns: []string{"ID", "STARTED", "OPERATION", "WORKSPACE", "FROM", "TO", "STEPS", "OUTCOME"}}
+	for _, record := range records {
+		rows.Values = append(rows.Values, []string{
+			strconv.Itoa(record.ID),
+			record.StartedAt.Format(time.RFC3339),
+			string(record.Operation),
+			record.Workspace,
+			describeState(record.From),
+			describeState(record.To),
+			strconv.Itoa(len(record.Steps)),
+			string(record.Outcome),
+		})
+	}
+	return rows
+}
+
+// summaryTable has a single row with the summary of a transition.
+func summaryTable(summary history.Record) output.Rows {
+	return output.Rows{
+		Columns: []string{"OPERATION", "STARTED", "ENDED", "FROM", "TO", "STEPS", "TAINTED", "OUTCOME"},
+		Values: [][]string{{
+			string(summary.Operation),
+			summary.StartedAt.Format(time.RFC3339),
+			summary.EndedAt.Format(time.RFC3339),
+			describeState(summary.From),
+			describeState(summary.To),
+			strconv.Itoa(len(summary.Steps)),
+			strconv.Itoa(len(summary.TaintedHosts)),
+			string(summary.Outcome),
+		}},
+	}
+}
+
+// uiWriter writes to the output of a cli.Ui, for an output.Stream.
+type uiWriter struct {
+	ui cli.Ui
+}
+
+func (u uiWriter) Write(p []byte) (int, error) {
+	u.ui.Output(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
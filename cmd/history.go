@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/carousel"
+	"github.com/xmidt-org/carousel/pkg/controller/pulumi"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/history"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/output"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const defaultHistoryFile = ".carousel/history.jsonl"
+
+type HistoryCommand struct {
+	Meta
+}
+
+func (c *HistoryCommand) Help() string {
+	helpText := `
+Usage: %s history [options]
+       %s history show <id> [options]
+
+  List the recorded rollouts, resumes and applies, oldest first.
+  show outputs a single record in detail.
+
+Options:
+
+  --workspace  Only list records of the workspace.
+  --version    Only list records with the version running before or after.
+  --json       Output the records as JSON, the same as --format json.
+  --format     Output format: table, json, yaml, csv or template=<go template>.
+               The list defaults to table, show defaults to a detailed description.
+`
+	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName, applicationName))
+}
+
+func (c *HistoryCommand) Synopsis() string {
+	return "show the history of rollouts"
+}
+
+func (c *HistoryCommand) Run(args []string) int {
+	var (
+		jsonOutput bool
+		format     string
+		filter     history.Filter
+	)
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.extendedFlagSet("history")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json output")
+	cmdFlags.StringVar(&filter.Workspace, "workspace", "", "only list records of the workspace")
+	cmdFlags.StringVar(&filter.Version, "version", "", "only list records with the version")
+	addFormatFlag(cmdFlags, &format)
+	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	formatter, structured, err := buildFormatter(format, jsonOutput)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	store := historyStore(c.Meta.readConfig())
+
+	switch {
+	case cmdFlags.NArg() == 0:
+		records, err := store.List(filter)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to read history: %v", err))
+			return 1
+		}
+		if !structured {
+			formatter, _ = output.NewFormatter(string(output.Table))
+		}
+		return c.output(formatter, records, recordsTable(records))
+	case cmdFlags.NArg() == 2 && cmdFlags.Arg(0) == "show":
+		id, err := strconv.Atoi(cmdFlags.Arg(1))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("invalid record id %s", cmdFlags.Arg(1)))
+			return 1
+		}
+		record, err := store.Get(id)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to read history: %v", err))
+			return 1
+		}
+		if structured {
+			return c.output(formatter, record, recordsTable([]history.Record{record}))
+		}
+		c.UI.Output(formatRecord(record))
+		return 0
+	default:
+		c.UI.Error(c.Help())
+		return 1
+	}
+}
+
+func (c *HistoryCommand) output(formatter output.Formatter, value interface{}, table output.Tabular) int {
+	result, err := formatter.Output(value, table)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("\nError formatting output: %s", err))
+		return 1
+	}
+	c.UI.Output(result)
+	return 0
+}
+
+// historyStore builds the history.Store of the HistoryConfig.
+func historyStore(config Config) history.Store {
+	if config.History.File == "" {
+		return history.NewStore(defaultHistoryFile)
+	}
+	return history.NewStore(config.History.File)
+}
+
+// recorder builds the carousel.Recorder of the HistoryConfig, nil if the history is disabled.
+func (m *TransitionMeta) recorder() carousel.Recorder {
+	if m.config.History.Disable {
+		return nil
+	}
+	workspace := m.config.Workspace
+	// the history should be filterable by workspace, so find out which one is selected.
+	// the exec controller can't tell, its workspace is only known if configured.
+	switch {
+	case workspace != "" || m.config.usesExec():
+	case m.config.usesPulumi():
+		workspace, _ = pulumi.BuildStackManager(m.config.BinaryConfig, false).CurrentWorkspace()
+	default:
+		workspace, _ = terraform.CurrentWorkspace(m.config.BinaryConfig)
+	}
+	return history.Recorder{
+		Store:     historyStore(*m.config),
+		Workspace: workspace,
+		Operator:  operator(m.config.History),
+	}
+}
+
+// operator returns the configured operator, or the name of the current user.
+func operator(config HistoryConfig) string {
+	if config.Operator != "" {
+		return config.Operator
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func formatRecord(record history.Record) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%d\n", record.ID)
+	fmt.Fprintf(w, "Operation:\t%s\n", record.Operation)
+	fmt.Fprintf(w, "Workspace:\t%s\n", record.Workspace)
+	fmt.Fprintf(w, "Operator:\t%s\n", record.Operator)
+	fmt.Fprintf(w, "Started:\t%s\n", record.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Ended:\t%s (%s)\n", record.EndedAt.Format(time.RFC3339), record.EndedAt.Sub(record.StartedAt).Round(time.Second))
+	fmt.Fprintf(w, "From:\t%s\n", describeState(record.From))
+	fmt.Fprintf(w, "To:\t%s\n", describeState(record.To))
+	fmt.Fprintf(w, "Outcome:\t%s\n", record.Outcome)
+	if record.Error != "" {
+		fmt.Fprintf(w, "Error:\t%s\n", record.Error)
+	}
+	if record.LogDir != "" {
+		fmt.Fprintf(w, "Logs:\t%s\n", record.LogDir)
+	}
+	w.Flush()
+
+	fmt.Fprintf(&buf, "Steps completed: %d\n", len(record.Steps))
+	for _, step := range record.Steps {
+		fmt.Fprintf(&buf, "\t%s\n", step)
+	}
+	if len(record.TaintedHosts) > 0 {
+		fmt.Fprintf(&buf, "Tainted hosts:\n")
+		for _, host := range record.TaintedHosts {
+			fmt.Fprintf(&buf, "\t%s\n", host)
+		}
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// describeState returns the version and node count of each Color Group with nodes, e.g. green 0.1.0 x2.
+func describeState(state model.ClusterState) string {
+	groups := make([]string, 0, len(model.ValidColors))
+	for _, color := range model.ValidColors {
+		if group := state[color]; group.Count > 0 {
+			groups = append(groups, fmt.Sprintf("%s %s x%d", color, group.Version, group.Count))
+		}
+	}
+	if len(groups) == 0 {
+		return "empty"
+	}
+	return strings.Join(groups, ", ")
+}
@@ -32,6 +32,28 @@ func main() {
 	}
 
 	commands := map[string]cli.CommandFactory{
+		"apply": func() (cli.Command, error) {
+			return &ApplyCommand{
+				TransitionMeta: TransitionMeta{
+					Meta: meta,
+				},
+			}, nil
+		},
+		"diff": func() (cli.Command, error) {
+			return &DiffCommand{
+				Meta: meta,
+			}, nil
+		},
+		"doctor": func() (cli.Command, error) {
+			return &DoctorCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history": func() (cli.Command, error) {
+			return &HistoryCommand{
+				Meta: meta,
+			}, nil
+		},
 		"taint": func() (cli.Command, error) {
 			return &TaintCommand{
 				Meta: meta,
@@ -61,6 +83,11 @@ func main() {
 				Meta: meta,
 			}, nil
 		},
+		"workspace": func() (cli.Command, error) {
+			return &WorkspaceCommand{
+				Meta: meta,
+			}, nil
+		},
 	}
 	c.Commands = commands
 
@@ -6,9 +6,15 @@ import (
 	"github.com/mitchellh/cli"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/controller/exec"
+	"github.com/xmidt-org/carousel/pkg/controller/pulumi"
 	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
 	"io/ioutil"
+	"os"
+	"strings"
 )
 
 // Meta based of the terraform Meta struct
@@ -22,6 +28,17 @@ type Meta struct {
 	file       string
 
 	config *Config
+	// configErrs are the errors found reading the config, they are already output.
+	configErrs []error
+	// reportConfigErrs keeps going with an invalid config so the configErrs can be reported,
+	// otherwise reading an invalid config exits.
+	reportConfigErrs bool
+	// capabilities of the binary, detected once.
+	capabilities *terraform.Capabilities
+	// capabilitiesErr is why the capabilities of the binary couldn't be detected.
+	capabilitiesErr error
+	// loaded is set once init ran and the workspace is selected.
+	loaded bool
 }
 
 // process will process the meta-parameters out of the arguments. This
@@ -76,7 +93,73 @@ func (m *Meta) defaultFlagSet(n string) *pflag.FlagSet {
 	return f
 }
 
+// LoadConfig reads the config and selects the configured workspace, init and the selection only run once.
 func (m *Meta) LoadConfig() Config {
+	m.readConfig()
+	if m.loaded {
+		return *m.config
+	}
+	m.loaded = true
+
+	if err := m.workspaceSelecter(!m.config.DisableWorkspaceCreation).SelectWorkspace(m.config.Workspace); err != nil {
+		var exitErr runner.ExitError
+
+		if errors.Is(err, controller.ErrWorkspaceNotFound) {
+			// carrying on would change the wrong workspace.
+			m.UI.Error(fmt.Sprintf("%v, workspace creation is disabled, create it with %s workspace new", err, applicationName))
+			os.Exit(1)
+		} else if errors.As(err, &exitErr) {
+			m.UI.Error(fmt.Sprintf("Failed to select workspace %#v", err))
+			m.UI.Output(string(exitErr.CapturedErrorOutput))
+		} else {
+			m.UI.Error(fmt.Sprintf("Failed to select workspace %#v", err))
+		}
+	} else {
+		if m.config.Workspace != "" {
+			m.UI.Warn(fmt.Sprintf("using workspace %s", m.config.Workspace))
+		}
+	}
+
+	return *m.config
+}
+
+// workspaceSelecter builds the WorkspaceSelecter of the controller of the config, exec runs its selectWorkspace command.
+func (m *Meta) workspaceSelecter(create bool) controller.WorkspaceSelecter {
+	config := m.readConfig()
+	if config.usesExec() {
+		return exec.BuildSelectWorkspaceRunner(config.BinaryConfig, config.Exec)
+	}
+	return m.workspaceManager(create)
+}
+
+// workspaceManager builds the WorkspaceManager of the controller of the config, a pulumi workspace is a stack.
+// If create is false, selecting a missing workspace fails instead of creating it.
+func (m *Meta) workspaceManager(create bool) controller.WorkspaceManager {
+	config := m.readConfig()
+	if config.usesPulumi() {
+		return pulumi.BuildStackManager(config.BinaryConfig, create)
+	}
+	return terraform.BuildWorkspaceManager(config.BinaryConfig, m.binaryCapabilities(), create)
+}
+
+// binaryCapabilities detects what the binary of the config supports, the binary is only run once.
+// An unsupported version exits, if the version can't be found the Capabilities of an unknown binary are used and the
+// error is kept in capabilitiesErr.
+func (m *Meta) binaryCapabilities() terraform.Capabilities {
+	if m.capabilities == nil {
+		capabilities, err := terraform.DetectCapabilities(m.readConfig().BinaryConfig)
+		if errors.Is(err, terraform.ErrUnsupportedVersion) {
+			m.UI.Error(err.Error())
+			os.Exit(1)
+		}
+		m.capabilities = &capabilities
+		m.capabilitiesErr = err
+	}
+	return *m.capabilities
+}
+
+// readConfig reads the config without running the binary, the config is only read once.
+func (m *Meta) readConfig() Config {
 	if m.config == nil {
 		v := viper.New()
 		if m.file != "" {
@@ -92,30 +175,73 @@ func (m *Meta) LoadConfig() Config {
 		if err := v.ReadInConfig(); err != nil {
 			// Should we print out the config file?
 			m.UI.Error(fmt.Sprintf("Failed to read config file: %v", err))
+			// without a config file the defaults are used.
+			var notFound viper.ConfigFileNotFoundError
+			if !errors.As(err, &notFound) {
+				m.configErrs = append(m.configErrs, err)
+			}
 		}
 		config := Config{}
 		if err := v.Unmarshal(&config); err != nil {
 			m.UI.Error(fmt.Sprintf("Failed to read config: %v", err))
+			m.configErrs = append(m.configErrs, err)
+		}
+		if len(config.Groups) > 0 {
+			if err := model.SetColorGroups(config.Groups); err != nil {
+				m.UI.Error(fmt.Sprintf("Failed to configure groups: %v", err))
+				m.configErrs = append(m.configErrs, err)
+			}
+		}
+		switch strings.ToLower(config.Controller) {
+		case "", terraformController, pulumiController:
+		case execController:
+			if err := config.Exec.Validate(); err != nil {
+				m.UI.Error(fmt.Sprintf("Failed to read config: %v", err))
+				m.configErrs = append(m.configErrs, err)
+			}
+		default:
+			// even doctor can't check anything, every check depends on the controller.
+			m.UI.Error(fmt.Sprintf("Failed to read config: unknown controller %s, try [%s, %s, %s]", config.Controller, terraformController, pulumiController, execController))
+			os.Exit(1)
 		}
 		m.config = &config
+		// carrying on would use the defaults instead of the config, e.g. the wrong groups.
+		if len(m.configErrs) > 0 && !m.reportConfigErrs {
+			os.Exit(1)
+		}
 	}
 
-	if err := terraform.BuildSelectWorkspaceRunner(m.config.BinaryConfig).SelectWorkspace(m.config.Workspace); err != nil {
-		var exitErr runner.ExitError
+	return *m.config
+}
 
-		if errors.As(err, &exitErr) {
-			m.UI.Error(fmt.Sprintf("Failed to select workspace %#v", err))
-			m.UI.Output(string(exitErr.CapturedErrorOutput))
-		} else {
-			m.UI.Error(fmt.Sprintf("Failed to select workspace %#v", err))
-		}
+// clusterGetter builds the ClusterGetter of the config. With a stateFile the cluster is read from the file,
+// so the binary isn't run and the workspace isn't selected.
+func (m *Meta) clusterGetter(stateFile string) (controller.ClusterGetter, error) {
+	var config Config
+	if stateFile != "" {
+		config = m.readConfig()
 	} else {
-		if m.config.Workspace != "" {
-			m.UI.Warn(fmt.Sprintf("using workspace %s", m.config.Workspace))
+		config = m.LoadConfig()
+	}
+	if config.usesExec() {
+		if stateFile != "" {
+			return exec.BuildClusterFileDeterminer(stateFile), nil
 		}
+		return exec.BuildStateDeterminer(config.BinaryConfig, config.Exec)
 	}
-
-	return *m.config
+	naming, err := terraform.BuildNaming(config.Naming)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read naming config: %w", err)
+	}
+	switch {
+	case config.usesPulumi() && stateFile != "":
+		return pulumi.BuildOutputsFileDeterminer(stateFile, naming), nil
+	case config.usesPulumi():
+		return pulumi.BuildStateDeterminer(config.BinaryConfig, naming), nil
+	case stateFile != "":
+		return terraform.BuildStateFileDeterminer(stateFile, naming), nil
+	}
+	return terraform.BuildStateDeterminer(config.BinaryConfig, naming), nil
 }
 
 // extendedFlagSet adds custom flags that are mostly used by commands
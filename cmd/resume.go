@@ -1,11 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/resume"
 	"io/ioutil"
 	"strings"
+	"time"
 )
 
 type ResumeCommand struct {
@@ -17,11 +17,18 @@ func (c *ResumeCommand) Help() string {
 Usage: %s resume <step_file> [options]
 
  The terraform .tf files must contain a green module and blue module.
- resume will resume a failed transition from a
+ resume will resume a failed transition from a step file.
+ Step files written by older versions of carousel are migrated when read.
+ The steps are checked with the batch size and skip first n they were built with, older step files use the config.
+ If the cluster doesn't match the first step, the remaining steps are reconciled with the current cluster.
+ The reconciled steps are shown and only applied once confirmed, --yes applies them without asking.
 
 Options:
 
-  -json       Output the version information as a JSON object.
+  -json       Output the progress and summary as JSON, the same as --format json.
+  --format    Output format of the progress and summary: table, json, yaml, csv or template=<go template>.
+  --safe      Plan each step and abort if the plan creates or destroys unexpected resources.
+  --yes       Apply the reconciled steps without asking.
 `
 	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName))
 }
@@ -33,10 +40,15 @@ func (c *ResumeCommand) Synopsis() string {
 func (c *ResumeCommand) Run(args []string) int {
 	args = c.Meta.process(args)
 	cmdFlags := c.TransitionMeta.transitionFlagSet("resume")
+	var yes bool
+	cmdFlags.BoolVar(&yes, "yes", false, "apply the reconciled steps without asking")
 	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
+	if !yes {
+		c.TransitionMeta.confirm = c.TransitionMeta.ask
+	}
 
 	if cmdFlags.NArg() != 1 {
 		c.UI.Error("only one arguments must be provide")
@@ -47,14 +59,28 @@ func (c *ResumeCommand) Run(args []string) int {
 	data, err := ioutil.ReadFile(cmdFlags.Arg(0))
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("failed to read error file %v", err))
+		return 1
 	}
-	var stepError model.StepError
-	err = json.Unmarshal(data, &stepError)
+	// the groups of the file are only valid once the configured groups are set.
+	c.Meta.readConfig()
+	file, err := resume.Load(data)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("failed to read error file %v", err))
 		return 1
 	}
 
-	err = c.TransitionMeta.getCarousel().Resume(stepError.StartingColorGroup, stepError.TODO, stepError.GoalClusterState)
+	carousel := c.TransitionMeta.getCarousel()
+	if file.Workspace != "" && file.Workspace != c.config.Workspace {
+		c.UI.Warn(fmt.Sprintf("step file was written for workspace %s", file.Workspace))
+	}
+	if file.Cause != "" {
+		c.UI.Info(fmt.Sprintf("resuming transition that failed at %s: %s", file.FailedAt.Format(time.RFC3339), file.Cause))
+	}
+	// the steps are only valid for the rollout they were built with, e.g. the batch size of an apply.
+	rollout := c.TransitionMeta.configRollout()
+	if file.Rollout != nil {
+		rollout = *file.Rollout
+	}
+	err = carousel.ResumeFrom(file.StepError(), c.TransitionMeta.stepOptions(rollout)...)
 	return c.handleExitError(err)
 }
@@ -20,7 +20,10 @@ Usage: %s rollout <server count> <version> [options]
 
 Options:
 
-  -json       Output the version information as a JSON object.
+  -json       Output the progress and summary as JSON, the same as --format json.
+  --format    Output format of the progress and summary: table, json, yaml, csv or template=<go template>.
+  --force     Rollout the version even if the version policy refuses it.
+  --safe      Plan each step and abort if the plan creates or destroys unexpected resources.
 `
 	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName))
 }
@@ -32,6 +35,7 @@ func (c *RolloutCommand) Synopsis() string {
 func (c *RolloutCommand) Run(args []string) int {
 	args = c.Meta.process(args)
 	cmdFlags := c.TransitionMeta.transitionFlagSet("rollout")
+	cmdFlags.BoolVar(&c.TransitionMeta.force, "force", false, "ignore the version policy")
 	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
@@ -52,6 +56,12 @@ func (c *RolloutCommand) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Failed to determine version of servers to deploy %v", err))
 	}
 
-	err = c.TransitionMeta.getCarousel().Rollout(serverCount, version)
+	// nothing is run for a refused version, the current version is checked once the cluster is read.
+	if err := c.TransitionMeta.checkVersion(version); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	carousel := c.TransitionMeta.getCarousel()
+	err = carousel.Rollout(serverCount, version, c.TransitionMeta.stepOptions(c.TransitionMeta.configRollout())...)
 	return c.handleExitError(err)
 }
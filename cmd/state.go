@@ -3,9 +3,13 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/diff"
 	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/output"
+	"io/ioutil"
 	"strings"
+	"time"
 )
 
 type StateCommand struct {
@@ -20,8 +24,15 @@ Usage: %s state [options]
 
 Options:
 
-  --json       Output the cluster information as a JSON object.
+  --json       Output the cluster information as a JSON object, the same as --format json.
+  --format     Output format: table, json, yaml, csv or template=<go template>.
   --full       Output the hostnames of the cluster.
+  --save       Write the cluster to a snapshot file, to compare with diff later.
+  --watch      Keep polling the cluster and output the changes, --json outputs a JSON line per change.
+               With --format each change is output in the format, as yaml documents or csv rows.
+  --interval   The interval to poll the cluster with --watch. Default 10s.
+  --state-file Read the cluster from a local state file instead of running terraform state pull,
+               e.g. terraform.tfstate or a file written by terraform state pull.
 
 `
 	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName))
@@ -34,51 +45,157 @@ func (c *StateCommand) Synopsis() string {
 func (c *StateCommand) Run(args []string) int {
 	var jsonOutput bool
 	var fullOutput bool
+	var snapshotFile string
+	var watch bool
+	var interval time.Duration
+	var format string
+	var stateFile string
 
 	args = c.Meta.process(args)
 	cmdFlags := c.Meta.extendedFlagSet("state")
 	cmdFlags.BoolVar(&jsonOutput, "json", false, "json output")
 	cmdFlags.BoolVar(&fullOutput, "full", false, "print hostnames")
+	cmdFlags.StringVar(&snapshotFile, "save", "", "snapshot file to write the cluster to")
+	cmdFlags.BoolVar(&watch, "watch", false, "keep polling the cluster and output the changes")
+	cmdFlags.DurationVar(&interval, "interval", 10*time.Second, "interval to poll the cluster with --watch")
+	cmdFlags.StringVar(&stateFile, "state-file", "", "state file to read the cluster from instead of pulling the state")
+	addFormatFlag(cmdFlags, &format)
 	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
 	if err := cmdFlags.Parse(args); err != nil {
 		return 1
 	}
-	config := c.Meta.LoadConfig()
-
-	cluster, err := terraform.BuildStateDeterminer(config.BinaryConfig).GetCluster()
+	if watch && interval <= 0 {
+		c.UI.Error("interval must be greater than 0")
+		return 1
+	}
+	stateDeterminer, err := c.Meta.clusterGetter(stateFile)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	cluster, err := stateDeterminer.GetCluster()
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to get Cluster state: \n %v", err))
 		return 1
 	}
 
-	if jsonOutput {
+	if snapshotFile != "" {
+		data, _ := json.MarshalIndent(cluster, "", "  ")
+		if err := ioutil.WriteFile(snapshotFile, data, 0644); err != nil {
+			c.UI.Error(fmt.Sprintf("failed to write to file %s", snapshotFile))
+			return 1
+		}
+	}
+
+	formatter, structured, err := buildFormatter(format, jsonOutput)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if watch {
+		var stream *output.Stream
+		if structured {
+			stream = output.NewStream(formatter, uiWriter{c.UI})
+		}
+		return c.watch(stateDeterminer, cluster, interval, stream)
+	}
+
+	if structured {
+		var result string
 		if fullOutput {
-			data, err := json.MarshalIndent(cluster, "", "  ")
-			if err != nil {
-				c.UI.Error(fmt.Sprintf("\nError marshaling JSON: %s", err))
-				return 1
-			}
-			c.UI.Output(string(data))
+			result, err = formatter.Output(cluster, clusterTable(cluster))
 		} else {
-			data, err := json.MarshalIndent(cluster.AsClusterState(), "", "  ")
-			if err != nil {
-				c.UI.Error(fmt.Sprintf("\nError marshaling JSON: %s", err))
-				return 1
+			result, err = formatter.Output(cluster.AsClusterState(), clusterStateTable(cluster.AsClusterState()))
+		}
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("\nError formatting output: %s", err))
+			return 1
+		}
+		c.UI.Output(result)
+		return 0
+	}
+
+	if fullOutput {
+		for _, groupColor := range model.ValidColors {
+			c.UI.Output(fmt.Sprintf("%s @ %s", groupColor, cluster[groupColor].Version))
+			for _, host := range cluster[groupColor].Hosts {
+				c.UI.Output(fmt.Sprintf("\t%s", describeHost(cluster[groupColor].Host(host))))
 			}
-			c.UI.Output(string(data))
 		}
 	} else {
-		if fullOutput {
-			for _, groupColor := range model.ValidColors {
-				c.UI.Output(fmt.Sprintf("%s @ %s", groupColor, cluster[groupColor].Version))
-				for _, host := range cluster[groupColor].Hosts {
-					c.UI.Output(fmt.Sprintf("\t%s", host))
-				}
+		c.UI.Info(cluster.AsClusterState().String())
+	}
+
+	return 0
+}
+
+// watchEvent is the output of each change found by state --watch.
+type watchEvent struct {
+	Time    time.Time `json:"time"`
+	Changes diff.Diff `json:"changes"`
+}
+
+// watch polls the cluster until shutdown and outputs the changes between each poll.
+// With a stream, the first event has the changes from an empty cluster, so the current cluster is known.
+func (c *StateCommand) watch(stateDeterminer controller.ClusterGetter, cluster model.Cluster, interval time.Duration, stream *output.Stream) int {
+	if stream != nil {
+		c.outputWatchEvent(stream, diff.Clusters(model.NewCluster(), cluster).Changes())
+	} else {
+		c.UI.Info(fmt.Sprintf("watching %s every %s", cluster.AsClusterState(), interval))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ShutdownCh:
+			return 0
+		case <-ticker.C:
+			current, err := stateDeterminer.GetCluster()
+			if err != nil {
+				c.UI.Warn(fmt.Sprintf("Failed to get Cluster state: %v", err))
+				continue
 			}
-		} else {
-			c.UI.Info(cluster.AsClusterState().String())
+			changes := diff.Clusters(cluster, current).Changes()
+			cluster = current
+			if len(changes) == 0 {
+				continue
+			}
+			if stream != nil {
+				c.outputWatchEvent(stream, changes)
+				continue
+			}
+			c.UI.Output(fmt.Sprintf("%s %s", time.Now().Format(time.RFC3339), cluster.AsClusterState()))
+			c.UI.Output(formatDiff(changes))
 		}
 	}
+}
 
-	return 0
+func (c *StateCommand) outputWatchEvent(stream *output.Stream, changes diff.Diff) {
+	event := watchEvent{Time: time.Now().UTC(), Changes: changes}
+	table := diffTable(changes)
+	table.Columns = append([]string{"TIME"}, table.Columns...)
+	for i, row := range table.Values {
+		table.Values[i] = append([]string{event.Time.Format(time.RFC3339)}, row...)
+	}
+	if err := stream.Write(event, table); err != nil {
+		c.UI.Error(fmt.Sprintf("\nError formatting output: %s", err))
+	}
+}
+
+// describeHost returns the fqdn followed by the metadata of the host, e.g. a.example.com ip=10.0.0.1 zone=us-east-1a.
+func describeHost(host model.Host) string {
+	fields := []string{host.FQDN}
+	for _, field := range []struct{ name, value string }{
+		{"key", host.Key},
+		{"ip", host.IP},
+		{"zone", host.Zone},
+		{"address", host.Address},
+	} {
+		if field.value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%s", field.name, field.value))
+		}
+	}
+	return strings.Join(fields, " ")
 }
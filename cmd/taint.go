@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/controller/exec"
 	"github.com/xmidt-org/carousel/pkg/controller/terraform"
 	"strings"
 )
@@ -43,9 +45,27 @@ func (c *TaintCommand) Run(args []string) int {
 		c.UI.Error(c.Help())
 		return 1
 	}
-	clusterGetter := terraform.BuildStateDeterminer(config.BinaryConfig)
-	grapher := terraform.BuildClusterGraphRunner(clusterGetter, config.BinaryConfig)
-	tainter := terraform.BuildTaintHostRunner(grapher, config.BinaryConfig)
+	var tainter controller.Tainter
+	switch {
+	case config.usesExec():
+		var err error
+		if tainter, err = exec.BuildTaintHostRunner(config.BinaryConfig, config.Exec); err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to read exec config: %v", err))
+			return 1
+		}
+	case config.usesPulumi():
+		c.UI.Error("pulumi has no taint, failed hosts are replaced by the next rollout")
+		return 1
+	default:
+		naming, err := terraform.BuildNaming(config.Naming)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to read naming config: %v", err))
+			return 1
+		}
+		clusterGetter := terraform.BuildStateDeterminer(config.BinaryConfig, naming)
+		grapher := terraform.BuildClusterGraphRunner(clusterGetter, config.BinaryConfig, naming)
+		tainter = terraform.BuildTaintHostRunner(grapher, config.BinaryConfig)
+	}
 
 	for i := 0; i < hostCount; i++ {
 		hostname := cmdFlags.Arg(i)
@@ -4,16 +4,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/blang/semver/v4"
 	"github.com/spf13/pflag"
 	"github.com/xmidt-org/carousel/pkg/carousel"
 	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/controller/exec"
+	"github.com/xmidt-org/carousel/pkg/controller/pulumi"
 	"github.com/xmidt-org/carousel/pkg/controller/terraform"
 	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/output"
+	"github.com/xmidt-org/carousel/pkg/policy"
+	"github.com/xmidt-org/carousel/pkg/resume"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"github.com/xmidt-org/carousel/pkg/step"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"plugin"
+	"strings"
+	"time"
 )
 
+// redactedValue replaces private values in the config written to a resume file.
+const redactedValue = "xxxx"
+
+// defaultOutputLogDir is the directory of the output logs if the OutputLogConfig has none.
+const defaultOutputLogDir = ".carousel/logs"
+
 type TransitionMeta struct {
 	Meta
 	jsonOutput bool
@@ -22,48 +39,171 @@ type TransitionMeta struct {
 	dryRun     bool
 	pluginFile string
 	outputFile string
+	force      bool
+	safe       bool
+	startedAt  time.Time
+	format     string
+	// formatted is set if the progress and summary are output in a format.
+	formatted bool
+	// outputLog keeps the output of the commands of the transition, nil if disabled.
+	outputLog *runner.OutputLog
+	// rollout is the batch size and skip first n the steps are built with, saved in the resume file.
+	rollout *resume.Rollout
+	// confirm asks before reconciled steps are applied, nil applies them without asking.
+	confirm func(message string) bool
 }
 
 // transitionFlagSet adds custom flags that are mostly used by commands
 // that are used to run a transition operation like rollout or resume.
 func (m *TransitionMeta) transitionFlagSet(n string) *pflag.FlagSet {
 	cmdFlags := m.extendedFlagSet(n)
-	cmdFlags.BoolVar(&m.jsonOutput, "json", false, "json output")
+	m.addTransitionFlags(cmdFlags)
+	return cmdFlags
+}
+
+// addTransitionFlags adds the flags shared by the transition commands to the flag set.
+func (m *TransitionMeta) addTransitionFlags(cmdFlags *pflag.FlagSet) {
+	cmdFlags.BoolVar(&m.jsonOutput, "json", false, "json output, the same as --format json")
 	cmdFlags.BoolVar(&m.fullOutput, "full", false, "print hostnames")
+	_ = cmdFlags.MarkDeprecated("full", "the hostnames are not part of the transition output")
+	addFormatFlag(cmdFlags, &m.format)
 	cmdFlags.BoolVarP(&m.notQuiet, "quiet", "q", false, "print terraform output")
 	cmdFlags.BoolVarP(&m.dryRun, "dry-run", "d", false, "print command to be executed")
 	cmdFlags.StringVarP(&m.pluginFile, "plugin", "p", "", "golang plugin file for validating hosts")
-	cmdFlags.StringVarP(&m.outputFile, "output", "o", "err.json", "output file for steps upon error")
-
-	return cmdFlags
+	cmdFlags.StringVarP(&m.outputFile, "output", "o", "err.json", "output file for steps upon error, a timestamp is added to the name")
+	cmdFlags.BoolVar(&m.safe, "safe", false, "plan each step and check the plan before applying it")
 }
 
 func (m *TransitionMeta) getController() controller.Controller {
 	m.LoadConfig()
 	transitionConfig := terraform.TerraformTransitionConfig{
-		AttachStdOut: !m.notQuiet,
+		// formatted output is the only thing written to stdout.
+		AttachStdOut: !m.notQuiet && !m.formatted,
 		AttachStdErr: true,
 		Args:         m.config.BinaryConfig.Args,
+		SafeMode:     m.config.SafeMode || m.safe,
+	}
+	if m.config.usesExec() {
+		return m.execController(transitionConfig)
+	}
+	naming, err := terraform.BuildNaming(m.config.Naming)
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read naming config: %v", err))
+		os.Exit(1)
+	}
+	transitionConfig.Tuning, err = stepTuning(m.config.RolloutConfig)
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read rollout config: %v", err))
+		os.Exit(1)
+	}
+	if m.config.usesPulumi() {
+		return m.pulumiController(transitionConfig, naming)
+	}
+	replacement, err := terraform.ParseHostReplacement(m.config.HostReplacement)
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read host replacement config: %v", err))
+		os.Exit(1)
+	}
+	transitionConfig.Capabilities = m.binaryCapabilities()
+	if m.capabilitiesErr != nil {
+		// only a transition replaces hosts.
+		m.UI.Warn(fmt.Sprintf("failed to detect the binary version, failed hosts will be tainted: %v", m.capabilitiesErr))
+	}
+	transitionConfig.HostReplacement, err = terraform.ResolveHostReplacement(transitionConfig.Capabilities, replacement)
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read host replacement config: %v", err))
+		os.Exit(1)
 	}
 
-	return terraform.BuildController(m.config.BinaryConfig, transitionConfig)
+	return terraform.BuildController(m.config.BinaryConfig, transitionConfig, naming)
+}
+
+// pulumiController builds the pulumi controller.Controller, the terraform only options fail or are ignored with a warning.
+func (m *TransitionMeta) pulumiController(transitionConfig terraform.TerraformTransitionConfig, naming terraform.Naming) controller.Controller {
+	if transitionConfig.SafeMode {
+		m.UI.Error("safe mode isn't supported by pulumi")
+		os.Exit(1)
+	}
+	if len(m.config.BinaryConfig.PrivateArgs) > 0 {
+		m.UI.Warn("privateArgs are ignored by pulumi, store them with pulumi config set --secret")
+	}
+	if len(m.config.BinaryConfig.VarFiles) > 0 {
+		m.UI.Warn("varFiles are ignored by pulumi, set the stack config with pulumi config set")
+	}
+	if init := m.config.BinaryConfig.Init; len(init.BackendConfigFiles) > 0 || len(init.BackendConfig) > 0 ||
+		init.Upgrade || init.Reconfigure || len(init.Args) > 0 {
+		m.UI.Warn("init is ignored by pulumi, log in to the backend with pulumi login")
+	}
+	if transitionConfig.Tuning.LockTimeout != "" || transitionConfig.Tuning.TargetGroups {
+		m.UI.Warn("lockTimeout and targetGroups are ignored by pulumi")
+	}
+	// pulumi has no taint, failed hosts are always replaced with up --replace.
+	if m.config.HostReplacement != "" {
+		m.UI.Warn("hostReplacement is ignored by pulumi, failed hosts are always replaced with up --replace")
+	}
+	return pulumi.BuildController(m.config.BinaryConfig, pulumi.PulumiTransitionConfig{
+		Args:         transitionConfig.Args,
+		AttachStdOut: transitionConfig.AttachStdOut,
+		AttachStdErr: transitionConfig.AttachStdErr,
+		Parallelism:  transitionConfig.Tuning.Parallelism,
+		Refresh:      transitionConfig.Tuning.Refresh,
+	}, naming)
+}
+
+// execController builds the exec controller.Controller, the terraform only options fail.
+func (m *TransitionMeta) execController(transitionConfig terraform.TerraformTransitionConfig) controller.Controller {
+	if transitionConfig.SafeMode {
+		m.UI.Error("safe mode isn't supported by the exec controller")
+		os.Exit(1)
+	}
+	execController, err := exec.BuildController(m.config.BinaryConfig, m.config.Exec, exec.ExecTransitionConfig{
+		AttachStdOut: transitionConfig.AttachStdOut,
+		AttachStdErr: transitionConfig.AttachStdErr,
+	})
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read exec config: %v", err))
+		os.Exit(1)
+	}
+	return execController
 }
 
 func (m *TransitionMeta) getCarousel() carousel.Carousel {
-	validator, err := m.extractValidatorFromPlugin()
+	m.startedAt = time.Now()
+	m.startOutputLog()
+	formatter, structured, err := buildFormatter(m.format, m.jsonOutput)
+	if err != nil {
+		m.UI.Error(err.Error())
+		os.Exit(1)
+	}
+	var reporter carousel.Reporter
+	if structured {
+		m.formatted = true
+		m.UI = stderrUI()
+		reporter = formatReporter{stream: output.NewStream(formatter, os.Stdout), ui: m.UI}
+	}
+
+	validator, detailsValidator, err := m.extractValidatorFromPlugin()
 	if err != nil {
 		m.UI.Error(fmt.Sprintf("Failed to load plugin: %s", err.Error()))
 	}
 
 	// no validator, return true for each host
-	if validator == nil {
+	if validator == nil && detailsValidator == nil {
 		m.UI.Warn("not checking hosts")
 		validator = func(fqdn string) bool { return true }
 	}
 
-	carousel, err := carousel.NewCarousel(&UILogger{m.UI}, m.UI, m.getController(), carousel.Config{
-		DryRun:   m.dryRun,
-		Validate: validator,
+	controller := m.getController()
+	carousel, err := carousel.NewCarousel(&UILogger{m.UI}, m.UI, controller, carousel.Config{
+		DryRun:          m.dryRun,
+		Validate:        validator,
+		ValidateDetails: detailsValidator,
+		VersionPolicy:   m.versionPolicy(),
+		Force:           m.force,
+		Recorder:        m.recorder(),
+		Reporter:        reporter,
+		OutputLog:       m.carouselOutputLog(),
+		Confirm:         m.confirm,
 	})
 	if err != nil {
 		m.UI.Error(err.Error())
@@ -72,32 +212,124 @@ func (m *TransitionMeta) getCarousel() carousel.Carousel {
 	return carousel
 }
 
-func (m *TransitionMeta) extractValidatorFromPlugin() (carousel.HostValidator, error) {
+// ask asks the user to confirm the message, anything but yes declines.
+func (m *TransitionMeta) ask(message string) bool {
+	answer, err := m.UI.Ask(fmt.Sprintf("%s Only 'yes' will be accepted:", message))
+	return err == nil && strings.TrimSpace(answer) == "yes"
+}
+
+// versionPolicy builds the VersionPolicy of the config, an invalid policy exits.
+func (m *TransitionMeta) versionPolicy() policy.VersionPolicy {
+	versionPolicy, err := policy.NewVersionPolicy(m.readConfig().VersionPolicy)
+	if err != nil {
+		m.UI.Error(fmt.Sprintf("Failed to read version policy: %v", err))
+		os.Exit(1)
+	}
+	return versionPolicy
+}
+
+// checkVersion checks the version with the version policy before the binary is run, the checks against the current
+// cluster are left to the rollout. With --force the violations are only a warning.
+func (m *TransitionMeta) checkVersion(version semver.Version) error {
+	err := m.versionPolicy().CheckVersion(version)
+	if err == nil {
+		return nil
+	}
+	if m.force {
+		m.UI.Warn(fmt.Sprintf("version policy overridden: %v", err))
+		return nil
+	}
+	return fmt.Errorf("%w: %v", carousel.ErrVersionPolicy, err)
+}
+
+// startOutputLog makes every command of the transition keep its output in a directory of the OutputLogConfig named
+// by the start time, e.g. .carousel/logs/20060102T150405Z. Nothing is kept for a dry run.
+func (m *TransitionMeta) startOutputLog() {
+	config := m.readConfig()
+	if config.OutputLog.Disable || m.dryRun {
+		return
+	}
+	dir := config.OutputLog.Dir
+	if dir == "" {
+		dir = defaultOutputLogDir
+	}
+	m.outputLog = runner.NewOutputLog(filepath.Join(dir, m.startedAt.UTC().Format(resume.TimestampFormat)))
+	runner.SetOutputLog(m.outputLog)
+}
+
+// carouselOutputLog returns the outputLog as a carousel.OutputLog, nil if disabled.
+func (m *TransitionMeta) carouselOutputLog() carousel.OutputLog {
+	if m.outputLog == nil {
+		return nil
+	}
+	return m.outputLog
+}
+
+// stepTuning builds the terraform.StepTuning from the RolloutConfig.
+func stepTuning(config RolloutConfig) (terraform.StepTuning, error) {
+	refresh, err := terraform.ParseRefresh(config.Refresh)
+	if err != nil {
+		return terraform.StepTuning{}, err
+	}
+	tuning := terraform.StepTuning{
+		Parallelism:  config.Parallelism,
+		Refresh:      refresh,
+		LockTimeout:  config.LockTimeout,
+		TargetGroups: config.TargetGroups,
+	}
+	return tuning, tuning.Validate()
+}
+
+// configRollout returns the batch size and skip first n of the RolloutConfig.
+func (m *TransitionMeta) configRollout() resume.Rollout {
+	return resume.Rollout{
+		BatchSize:  m.config.RolloutConfig.BatchSize,
+		SkipFirstN: m.config.RolloutConfig.SkipFirstN,
+	}
+}
+
+// stepOptions builds the step.StepOptions of the rollout the steps are built with.
+// The rollout is saved in the resume file, so a failed transition is resumed with the same constraints.
+func (m *TransitionMeta) stepOptions(rollout resume.Rollout) []step.StepOptions {
+	m.rollout = &rollout
+	return rollout.StepOptions()
+}
+
+// extractValidatorFromPlugin looks up CheckHostDetails in the plugin file, or CheckHost if it isn't defined.
+func (m *TransitionMeta) extractValidatorFromPlugin() (carousel.HostValidator, carousel.HostDetailsValidator, error) {
 	if m.pluginFile == "" {
-		return nil, nil
-	}
-	if p, err := plugin.Open(m.pluginFile); err == nil {
-		if f, lookupErr := p.Lookup("CheckHost"); lookupErr == nil {
-			if checkHostF, ok := f.(func(string) bool); ok {
-				if checkHostF != nil {
-					return carousel.AsHostValidator(checkHostF), nil
-				} else {
-					return nil, fmt.Errorf("CheckHost is nil")
-				}
-			} else if checkHost, ok := f.(carousel.HostValidator); ok {
-				if checkHost != nil {
-					return checkHost, nil
-				} else {
-					return nil, fmt.Errorf("CheckHost is nil")
-				}
+		return nil, nil, nil
+	}
+	p, err := plugin.Open(m.pluginFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f, lookupErr := p.Lookup("CheckHostDetails"); lookupErr == nil {
+		if checkHostF, ok := f.(func(model.Host) bool); ok && checkHostF != nil {
+			return nil, carousel.AsHostDetailsValidator(checkHostF), nil
+		} else if checkHost, ok := f.(*carousel.HostDetailsValidator); ok && checkHost != nil && *checkHost != nil {
+			return nil, *checkHost, nil
+		}
+		return nil, nil, fmt.Errorf("plugin file %s func CheckHostDetails is not a carousel.HostDetailsValidator", m.pluginFile)
+	}
+	if f, lookupErr := p.Lookup("CheckHost"); lookupErr == nil {
+		if checkHostF, ok := f.(func(string) bool); ok {
+			if checkHostF != nil {
+				return carousel.AsHostValidator(checkHostF), nil, nil
+			} else {
+				return nil, nil, fmt.Errorf("CheckHost is nil")
+			}
+		} else if checkHost, ok := f.(carousel.HostValidator); ok {
+			if checkHost != nil {
+				return checkHost, nil, nil
 			} else {
-				return nil, fmt.Errorf("plugin file %s func CheckHost is not a carousel.HostValidator", m.pluginFile)
+				return nil, nil, fmt.Errorf("CheckHost is nil")
 			}
 		} else {
-			return nil, fmt.Errorf("%w: %s", lookupErr, "CheckHost not defined in plugin file")
+			return nil, nil, fmt.Errorf("plugin file %s func CheckHost is not a carousel.HostValidator", m.pluginFile)
 		}
 	} else {
-		return nil, err
+		return nil, nil, fmt.Errorf("%w: %s", lookupErr, "CheckHost not defined in plugin file")
 	}
 }
 
@@ -106,14 +338,50 @@ func (m *TransitionMeta) handleExitError(err error) int {
 		var stepError model.StepError
 
 		if errors.As(err, &stepError) {
-			data, _ := json.MarshalIndent(&stepError, "", " ")
-			if writeerr := ioutil.WriteFile(m.outputFile, data, 0644); writeerr != nil {
-				m.UI.Error(fmt.Sprintf("failed to write to file %s", m.outputFile))
+			data, _ := json.MarshalIndent(m.resumeFile(stepError), "", " ")
+			filename := resume.FileName(m.outputFile, time.Now())
+			if writeerr := ioutil.WriteFile(filename, data, 0644); writeerr != nil {
+				m.UI.Error(fmt.Sprintf("failed to write to file %s", filename))
 				m.UI.Info(string(data))
+			} else {
+				m.UI.Info(fmt.Sprintf("to resume run: %s resume %s", applicationName, filename))
 			}
 		}
+		if errors.Is(err, carousel.ErrNotConfirmed) {
+			m.UI.Info(fmt.Sprintf("to apply the reconciled steps without asking run: %s resume --yes", applicationName))
+		}
 		m.UI.Error(fmt.Sprintf("Failed to transition cluster:\n%v", err))
 		return 1
 	}
 	return 0
 }
+
+// resumeFile builds the resume.File of a failed transition, with the private values of the config redacted.
+func (m *TransitionMeta) resumeFile(stepError model.StepError) resume.File {
+	file := resume.New(stepError, m.startedAt, time.Now())
+	file.CarouselVersion = Version
+	if m.outputLog != nil {
+		file.LogDir = m.outputLog.Dir()
+	}
+	file.Rollout = m.rollout
+	if m.config != nil {
+		file.Workspace = m.config.Workspace
+		file.Config, _ = json.Marshal(redactConfig(*m.config))
+	}
+	return file
+}
+
+// redactConfig returns a copy of the config with the values of the PrivateArgs, Environment and BackendConfig replaced.
+func redactConfig(config Config) Config {
+	redact := func(pairs []model.ValuePair) []model.ValuePair {
+		redacted := make([]model.ValuePair, len(pairs))
+		for i, pair := range pairs {
+			redacted[i] = model.ValuePair{Key: pair.Key, Value: redactedValue}
+		}
+		return redacted
+	}
+	config.BinaryConfig.PrivateArgs = redact(config.BinaryConfig.PrivateArgs)
+	config.BinaryConfig.Environment = redact(config.BinaryConfig.Environment)
+	config.BinaryConfig.Init.BackendConfig = redact(config.BinaryConfig.Init.BackendConfig)
+	return config
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/output"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
+)
+
+type WorkspaceCommand struct {
+	Meta
+}
+
+// workspace is a workspace of the list, Current is true for the selected one.
+type workspace struct {
+	Name    string `json:"name" yaml:"name"`
+	Current bool   `json:"current" yaml:"current"`
+}
+
+func (c *WorkspaceCommand) Help() string {
+	helpText := `
+Usage: %s workspace list [options]
+       %s workspace show
+       %s workspace select <name>
+       %s workspace new <name>
+       %s workspace delete <name> [--force]
+
+  Manage the workspaces of the binary with the same init, backend config and
+  binary as rollout. The names must match exactly, select fails if the workspace
+  doesn't exist, use new to create it.
+
+Options:
+
+  --force      Delete the workspace even if it still has resources.
+  --json       Output the list as JSON, the same as --format json.
+  --format     Output format: table, json, yaml, csv or template=<go template>.
+`
+	return strings.TrimSpace(fmt.Sprintf(helpText, applicationName, applicationName, applicationName, applicationName, applicationName))
+}
+
+func (c *WorkspaceCommand) Synopsis() string {
+	return "list, show, select, create or delete workspaces"
+}
+
+func (c *WorkspaceCommand) Run(args []string) int {
+	var (
+		jsonOutput bool
+		format     string
+		force      bool
+	)
+
+	args = c.Meta.process(args)
+	cmdFlags := c.Meta.extendedFlagSet("workspace")
+	cmdFlags.BoolVar(&jsonOutput, "json", false, "json output")
+	cmdFlags.BoolVar(&force, "force", false, "delete the workspace even if it has resources")
+	addFormatFlag(cmdFlags, &format)
+	cmdFlags.Usage = func() { c.UI.Error(c.Help()) }
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+	formatter, structured, err := buildFormatter(format, jsonOutput)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if c.Meta.readConfig().usesExec() {
+		c.UI.Error("the exec controller only selects workspaces, with its selectWorkspace command")
+		return 1
+	}
+	// select never creates the workspace, even if the config allows it, new is used for that.
+	manager := c.Meta.workspaceManager(false)
+	switch {
+	case cmdFlags.NArg() == 1 && cmdFlags.Arg(0) == "list":
+		names, err := manager.ListWorkspaces()
+		if err != nil {
+			return c.fail("list workspaces", err)
+		}
+		current, err := manager.CurrentWorkspace()
+		if err != nil {
+			return c.fail("show workspace", err)
+		}
+		workspaces := make([]workspace, 0, len(names))
+		rows := output.Rows{Columns: []string{"WORKSPACE", "CURRENT"}}
+		for _, name := range names {
+			workspaces = append(workspaces, workspace{Name: name, Current: name == current})
+			rows.Values = append(rows.Values, []string{name, fmt.Sprint(name == current)})
+		}
+		if structured {
+			result, err := formatter.Output(workspaces, rows)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("\nError formatting output: %s", err))
+				return 1
+			}
+			c.UI.Output(result)
+			return 0
+		}
+		for _, w := range workspaces {
+			if w.Current {
+				c.UI.Output("* " + w.Name)
+			} else {
+				c.UI.Output("  " + w.Name)
+			}
+		}
+		return 0
+	case cmdFlags.NArg() == 1 && cmdFlags.Arg(0) == "show":
+		current, err := manager.CurrentWorkspace()
+		if err != nil {
+			return c.fail("show workspace", err)
+		}
+		c.UI.Output(current)
+		return 0
+	case cmdFlags.NArg() == 2 && cmdFlags.Arg(0) == "select":
+		if err := manager.SelectWorkspace(cmdFlags.Arg(1)); err != nil {
+			if errors.Is(err, controller.ErrWorkspaceNotFound) {
+				c.UI.Error(fmt.Sprintf("%v, create it with %s workspace new %s", err, applicationName, cmdFlags.Arg(1)))
+				return 1
+			}
+			return c.fail("select workspace", err)
+		}
+		c.UI.Output(fmt.Sprintf("Switched to workspace %s", cmdFlags.Arg(1)))
+		return 0
+	case cmdFlags.NArg() == 2 && cmdFlags.Arg(0) == "new":
+		if err := manager.NewWorkspace(cmdFlags.Arg(1)); err != nil {
+			return c.fail("create workspace", err)
+		}
+		c.UI.Output(fmt.Sprintf("Created workspace %s", cmdFlags.Arg(1)))
+		return 0
+	case cmdFlags.NArg() == 2 && cmdFlags.Arg(0) == "delete":
+		if err := manager.DeleteWorkspace(cmdFlags.Arg(1), force); err != nil {
+			return c.fail("delete workspace", err)
+		}
+		c.UI.Output(fmt.Sprintf("Deleted workspace %s", cmdFlags.Arg(1)))
+		return 0
+	default:
+		c.UI.Error(c.Help())
+		return 1
+	}
+}
+
+// fail outputs the error of the operation with the error output of the binary.
+func (c *WorkspaceCommand) fail(operation string, err error) int {
+	c.UI.Error(fmt.Sprintf("Failed to %s: %v", operation, err))
+	var exitErr runner.ExitError
+	if errors.As(err, &exitErr) {
+		c.UI.Output(string(exitErr.CapturedErrorOutput))
+	}
+	return 1
+}
@@ -4,19 +4,21 @@ import (
 	"errors"
 	"fmt"
 	"github.com/go-kit/kit/log/level"
+	"github.com/xmidt-org/carousel/pkg/controller"
 	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
+	"github.com/xmidt-org/carousel/pkg/step"
+	"strings"
 	"sync"
 )
 
 // transition will apply the given steps to get to a cluster state to its goal state.
 // the first step must match the current cluster.
-func (c Carousel) transition(currentCluster model.Cluster, currentGroup model.Color, steps []model.Step, goalCluster model.ClusterState) error {
+func (c Carousel) transition(r *run, currentCluster model.Cluster, currentGroup model.Color, steps []model.Step, goalCluster model.ClusterState) error {
+	if len(steps) == 0 {
+		return errors.New("no steps to apply")
+	}
 	if !currentCluster.AsClusterState().IsEmpty() {
-		if len(steps) < 2 {
-			return errors.New("len of steps must be greater than 2")
-		}
-
 		// check first step matches currentCluster
 		if !currentCluster.AsClusterState().EqualStep(steps[0]) {
 			return errors.New("current cluster doesn't match first step")
@@ -31,39 +33,80 @@ func (c Carousel) transition(currentCluster model.Cluster, currentGroup model.Co
 	// for each step apply it.
 	// on error take current and future steps and return them in addition to the error.
 	currentHosts := map[string]bool{}
-	for _, host := range currentCluster[currentGroup.Other()].Hosts {
-		currentHosts[host] = true
+	for _, group := range currentCluster {
+		for _, host := range group.Hosts {
+			currentHosts[host] = true
+		}
 	}
 
-	// run each step
+	// run each step, the hosts of the groups growing in a step are checked.
+	previous := step.AsStep(currentCluster.AsClusterState())
 	for index, step := range steps {
+		applyGroups := growingGroups(previous, step)
+		previous = step
 		applyRunner := c.controller.CreateApply(goalCluster, step)
 		if c.config.DryRun {
 			c.ui.Info(applyRunner.String())
 			continue
 		}
-		err := c.handleRun(applyRunner, currentHosts, currentGroup.Other())
+		if c.config.OutputLog != nil {
+			c.config.OutputLog.Step(index + 1)
+		}
+		err := c.handleRun(r, applyRunner, currentHosts, applyGroups)
 		if err != nil {
 			// TODO: better error handling
-			return model.StepError{
+			stepError := model.StepError{
 				Cause:              err,
 				TODO:               steps[index:],
 				OriginalCluster:    currentCluster,
 				StartingColorGroup: currentGroup,
 				GoalClusterState:   goalCluster,
 			}
+			// the replacements are lost with the controller, unlike a taint they are not part of the state.
+			var replaceErr controller.ReplaceError
+			if errors.As(err, &replaceErr) {
+				stepError.Replace = replaceErr.Resources
+			}
+			return stepError
+		}
+		progress := r.completed(step, index, len(steps))
+		c.ui.Info(fmt.Sprintf("completed step: %s", describeStep(step)))
+		if c.config.Reporter != nil {
+			c.config.Reporter.StepCompleted(progress)
 		}
-		c.ui.Info(fmt.Sprintf("completed step: blue with %d nodes and green with %d nodes", step[model.Blue], step[model.Green]))
 	}
 	return nil
 }
 
+// describeStep returns a human readable description of the node count of each Color Group,
+// e.g. blue with 1 nodes and green with 2 nodes.
+func describeStep(step model.Step) string {
+	groups := make([]string, 0, len(model.ValidColors))
+	for _, color := range model.ValidColors {
+		groups = append(groups, fmt.Sprintf("%s with %d nodes", color, step[color]))
+	}
+	return strings.Join(groups, " and ")
+}
+
+// growingGroups returns the groups with more nodes in the step than in the previous step, including pinned groups.
+func growingGroups(previous model.Step, step model.Step) []model.Color {
+	groups := make([]model.Color, 0, len(step))
+	for _, color := range model.ValidColors {
+		if step[color] > previous[color] {
+			groups = append(groups, color)
+		}
+	}
+	return groups
+}
+
 // handleRun runs a Runnable until an unrecoverable error occurs or all host created are valid.
-func (c Carousel) handleRun(applyRunner runner.Runnable, currHost map[string]bool, applyGroup model.Color) error {
+func (c Carousel) handleRun(r *run, applyRunner runner.Runnable, currHost map[string]bool, applyGroups []model.Color) error {
 	level.Debug(c.logger).Log("runner", applyRunner.String())
 
 	// aka. terraform apply step
 	out, err := applyRunner.Output()
+	// even a failed apply can change the cluster.
+	c.invalidate()
 	if err != nil {
 		// todo:// try some other handler logic
 		return model.RunnableError{
@@ -78,12 +121,14 @@ func (c Carousel) handleRun(applyRunner runner.Runnable, currHost map[string]boo
 		return err
 	}
 
-	hostsToCheck := make([]string, 0)
+	hostsToCheck := make([]model.Host, 0)
 
 	// check each new host to see if its valid.
-	for _, host := range newCluster[applyGroup].Hosts {
-		if !currHost[host] {
-			hostsToCheck = append(hostsToCheck, host)
+	for _, applyGroup := range applyGroups {
+		for _, host := range newCluster[applyGroup].Hosts {
+			if !currHost[host] {
+				hostsToCheck = append(hostsToCheck, newCluster[applyGroup].Host(host))
+			}
 		}
 	}
 	hostToCheckCount := len(hostsToCheck)
@@ -92,14 +137,16 @@ func (c Carousel) handleRun(applyRunner runner.Runnable, currHost map[string]boo
 	wg := new(sync.WaitGroup)
 	wg.Add(hostToCheckCount)
 	for _, host := range hostsToCheck {
-		go c.checkHost(host, errChan, reRunChan, currHost, wg)
+		go c.checkHost(r, host, errChan, reRunChan, currHost, wg)
 	}
 	wg.Wait()
+	// the failed hosts have been tainted.
+	c.invalidate()
 	close(errChan)
 	close(reRunChan)
 	// if a host is not valid we have to rerun the step.
 	for range reRunChan {
-		return c.handleRun(applyRunner, currHost, applyGroup)
+		return c.handleRun(r, applyRunner, currHost, applyGroups)
 	}
 
 	var taintingErrors model.Errors
@@ -114,13 +161,31 @@ func (c Carousel) handleRun(applyRunner runner.Runnable, currHost map[string]boo
 	return taintingErrors
 }
 
-func (c Carousel) checkHost(hostname string, errChan chan<- error, rerun chan<- bool, currHost map[string]bool, wg *sync.WaitGroup) {
-	if !c.config.Validate(hostname) {
+// invalidate drops the cluster cached by the controller, if it has a cache.
+func (c Carousel) invalidate() {
+	if cache, ok := c.controller.(controller.Cache); ok {
+		cache.Invalidate()
+	}
+}
+
+// validate checks the host with ValidateDetails if it is set, or with Validate.
+func (c Carousel) validate(host model.Host) bool {
+	if c.config.ValidateDetails != nil {
+		return c.config.ValidateDetails(host)
+	}
+	return c.config.Validate(host.FQDN)
+}
+
+func (c Carousel) checkHost(r *run, host model.Host, errChan chan<- error, rerun chan<- bool, currHost map[string]bool, wg *sync.WaitGroup) {
+	hostname := host.FQDN
+	if !c.validate(host) {
 		level.Debug(c.logger).Log("msg", "check failed", "host", hostname)
 
 		err := c.controller.TaintHost(hostname)
 		if err != nil {
 			errChan <- err
+		} else {
+			r.tainted(hostname)
 		}
 
 		// re run command and checks stuff	t.logger.Log(level.Key(), level.DebugValue(), "msg", cc.AsClusterState().String())
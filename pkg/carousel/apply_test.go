@@ -1,12 +1,20 @@
 package carousel
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/blang/semver/v4"
 	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/history"
 	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/resume"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
 	"testing"
+	"time"
 )
 
 type noopUI struct {
@@ -107,6 +115,167 @@ func TestTransitionWithHostValidation(t *testing.T) {
 	mock.AssertExpectationsForObjects(t, controller, r)
 }
 
+func TestTransitionChecksPinnedGroup(t *testing.T) {
+	assert := assert.New(t)
+	defer model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}})
+	assert.NoError(model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}, {Name: "canary", Pinned: true}}))
+	canary := model.Color("canary")
+	badHost := "canary-bad.example.com"
+	goodHost := "canary-good.example.com"
+
+	current := model.Cluster{
+		model.Green: model.ClusterGroup{Hosts: []string{"green-0.example.com"}, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroup{},
+		canary:      model.ClusterGroup{},
+	}
+	withCanary := func(host string) model.Cluster {
+		return model.Cluster{
+			model.Green: current[model.Green],
+			model.Blue:  model.ClusterGroup{},
+			canary:      model.ClusterGroup{Hosts: []string{host}, Version: semver.MustParse("0.2.0")},
+		}
+	}
+
+	controller := &MockController{}
+	controller.On("GetCluster").Return(current, nil).Once()
+	controller.On("GetCluster").Return(withCanary(badHost), nil).Once()
+	controller.On("GetCluster").Return(withCanary(goodHost), nil).Once()
+	controller.On("TaintHost", badHost).Return(nil).Once()
+
+	r := &MockRunner{}
+	r.On("Output").Return([]byte("building step"), nil)
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, mock.Anything).Return(r)
+
+	var checked []string
+	carousel := Carousel{
+		config: Config{
+			Validate: func(fqdn string) bool {
+				checked = append(checked, fqdn)
+				return fqdn != badHost
+			},
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	steps := []model.Step{
+		{model.Green: 1, model.Blue: 0, canary: 0},
+		{model.Green: 1, model.Blue: 0, canary: 1},
+	}
+	goal := model.ClusterState{
+		model.Green: model.ClusterGroupState{Count: 1, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroupState{},
+		canary:      model.ClusterGroupState{Count: 1, Version: semver.MustParse("0.2.0")},
+	}
+	err := carousel.transition(carousel.startRun(history.Apply), current, model.Green, steps, goal)
+	assert.NoError(err)
+	assert.Equal([]string{badHost, goodHost}, checked)
+
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
+
+func TestTransitionWithHostDetails(t *testing.T) {
+	assert := assert.New(t)
+	badHost := model.Host{FQDN: "carousel-demo-ea9412.example.com", IP: "10.0.0.2", Zone: "us-east-1b"}
+	goodHost := model.Host{FQDN: "carousel-demo-ffdbb6.example.com", IP: "10.0.0.1", Zone: "us-east-1a"}
+
+	controller := &MockController{}
+	controller.On("GetCluster").Return(emptyCluster, nil).Twice()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{badHost.FQDN},
+			Version: semver.MustParse("0.1.0"),
+			Details: []model.Host{badHost},
+		},
+		model.Blue: model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{goodHost.FQDN},
+			Version: semver.MustParse("0.1.0"),
+			Details: []model.Host{goodHost},
+		},
+		model.Blue: model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("TaintHost", badHost.FQDN).Return(nil).Once()
+
+	r := &MockRunner{}
+	r.On("Output").Return([]byte("building step"), nil)
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, mock.Anything).Return(r)
+
+	var checked []model.Host
+	carousel := Carousel{
+		config: Config{
+			Validate: func(fqdn string) bool {
+				assert.Fail("Validate is replaced by ValidateDetails")
+				return false
+			},
+			ValidateDetails: func(host model.Host) bool {
+				checked = append(checked, host)
+				return host.Zone != badHost.Zone
+			},
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	err := carousel.Rollout(1, semver.MustParse("0.1.0"))
+	assert.NoError(err)
+	assert.Equal([]model.Host{badHost, goodHost}, checked)
+
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
+
+func TestTransitionInvalidatesCache(t *testing.T) {
+	assert := assert.New(t)
+	badHost := "carousel-demo-ea9412.example.com"
+
+	controller := &MockCachingController{}
+	controller.On("GetCluster").Return(emptyCluster, nil).Twice()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{badHost},
+			Version: semver.MustParse("0.1.0"),
+		},
+		model.Blue: model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{"carousel-demo-ffdbb6.example.com"},
+			Version: semver.MustParse("0.1.0"),
+		},
+		model.Blue: model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("TaintHost", badHost).Return(nil).Once()
+	// after each of the three applies and after checking the hosts of each apply.
+	controller.On("Invalidate").Times(6)
+
+	r := &MockRunner{}
+	r.On("Output").Return([]byte("building step"), nil)
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, mock.Anything).Return(r)
+
+	carousel := Carousel{
+		config: Config{
+			Validate: func(fqdn string) bool {
+				return fqdn != badHost
+			},
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	err := carousel.Rollout(1, semver.MustParse("0.1.0"))
+	assert.NoError(err)
+
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
+
 func TestDryRunTransition(t *testing.T) {
 	assert := assert.New(t)
 
@@ -136,3 +305,554 @@ func TestDryRunTransition(t *testing.T) {
 
 	mock.AssertExpectationsForObjects(t, controller, r)
 }
+
+func TestResumeInvalidSteps(t *testing.T) {
+	assert := assert.New(t)
+
+	controller := &MockController{}
+	carousel := Carousel{
+		config: Config{
+			Validate: func(fqdn string) bool {
+				assert.Fail("validator should not have been called. ")
+				return true
+			},
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	goalCluster := model.ClusterState{
+		model.Green: model.ClusterGroupState{
+			Count:   2,
+			Version: semver.MustParse("0.1.0"),
+		},
+		model.Blue: model.ClusterGroupState{},
+	}
+	// hand edited steps that jump past the batch size
+	steps := []model.Step{
+		{model.Blue: 2, model.Green: 0},
+		{model.Blue: 2, model.Green: 2},
+		{model.Blue: 0, model.Green: 2},
+	}
+	err := carousel.Resume(model.Blue, steps, goalCluster)
+	assert.ErrorIs(err, ErrInvalidSteps)
+
+	mock.AssertExpectationsForObjects(t, controller)
+}
+
+func TestRolloutVersionPolicy(t *testing.T) {
+	assert := assert.New(t)
+	greenCluster := model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{"carousel-demo-ffdbb6.example.com"},
+			Version: semver.MustParse("0.2.0"),
+		},
+		model.Blue: model.ClusterGroup{},
+	}
+
+	controller := &MockController{}
+	controller.On("GetCluster").Return(greenCluster, nil).Twice()
+	r := &MockRunner{}
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, mock.Anything).Return(r)
+
+	carousel := Carousel{
+		config: Config{
+			DryRun:   true,
+			Validate: func(fqdn string) bool { return true },
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	// downgrade is refused before any step is built
+	err := carousel.Rollout(1, semver.MustParse("0.1.0"))
+	assert.ErrorIs(err, ErrVersionPolicy)
+	controller.AssertNotCalled(t, "CreateApply", mock.Anything, mock.Anything)
+
+	carousel.config.Force = true
+	err = carousel.Rollout(1, semver.MustParse("0.1.0"))
+	assert.NoError(err)
+
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
+
+func TestApplyDesiredState(t *testing.T) {
+	assert := assert.New(t)
+	greenCluster := model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{"carousel-demo-ffdbb6.example.com"},
+			Version: semver.MustParse("0.2.0"),
+		},
+		model.Blue: model.ClusterGroup{},
+	}
+
+	controller := &MockController{}
+	controller.On("GetCluster").Return(greenCluster, nil).Twice()
+	r := &MockRunner{}
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, model.Step{model.Blue: 0, model.Green: 1}).Return(r).Once()
+	controller.On("CreateApply", mock.Anything, model.Step{model.Blue: 0, model.Green: 2}).Return(r).Once()
+
+	carousel := Carousel{
+		config: Config{
+			DryRun:   true,
+			Validate: func(fqdn string) bool { return true },
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	// already matches, nothing is applied
+	err := carousel.Apply(model.DesiredState{Count: 1, Version: semver.MustParse("0.2.0")})
+	assert.NoError(err)
+
+	// same version scales the current group without tripping the version policy
+	err = carousel.Apply(model.DesiredState{Count: 2, Version: semver.MustParse("0.2.0")})
+	assert.NoError(err)
+
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
+
+func TestApplyOverrideResume(t *testing.T) {
+	assert := assert.New(t)
+	greenCluster := model.Cluster{
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"},
+			Version: semver.MustParse("0.1.0"),
+		},
+		model.Blue: model.ClusterGroup{},
+	}
+	desired := model.DesiredState{Group: model.Blue, Count: 4, Version: semver.MustParse("0.2.0")}
+	rollout := resume.Rollout{BatchSize: 2}
+
+	// the second step fails.
+	controller := &MockController{}
+	controller.On("GetCluster").Return(greenCluster, nil)
+	applied := &MockRunner{}
+	applied.On("Output").Return([]byte("applied"), nil).Once()
+	applied.On("String").Return("mock runner")
+	failed := &MockRunner{}
+	failed.On("Output").Return([]byte("failed"), errors.New("apply failed")).Once()
+	failed.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, model.Step{model.Blue: 0, model.Green: 4}).Return(applied).Once()
+	controller.On("CreateApply", mock.Anything, model.Step{model.Blue: 2, model.Green: 4}).Return(failed).Once()
+	carousel := Carousel{
+		config:     Config{Validate: func(fqdn string) bool { return true }},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+	err := carousel.Apply(desired, rollout.StepOptions()...)
+	var stepError model.StepError
+	assert.ErrorAs(err, &stepError)
+	mock.AssertExpectationsForObjects(t, controller, applied, failed)
+
+	file := resume.New(stepError, time.Now(), time.Now())
+	file.Rollout = &rollout
+	data, err := json.Marshal(file)
+	assert.NoError(err)
+	file, err = resume.Load(data)
+	assert.NoError(err)
+	if assert.NotNil(file.Rollout) {
+		assert.Equal(rollout, *file.Rollout)
+	}
+
+	// the steps of the override exceed the default batch size.
+	err = carousel.Resume(file.StartingColorGroup, file.TODO, file.GoalClusterState)
+	assert.ErrorIs(err, ErrInvalidSteps)
+
+	// the failed apply created the blue hosts.
+	resumedCluster := model.Cluster{
+		model.Green: greenCluster[model.Green],
+		model.Blue:  model.ClusterGroup{Hosts: []string{"e.example.com", "f.example.com"}, Version: semver.MustParse("0.2.0")},
+	}
+	controller = &MockController{}
+	controller.On("GetCluster").Return(resumedCluster, nil).Once()
+	r := &MockRunner{}
+	r.On("String").Return("mock runner")
+	for _, s := range file.TODO {
+		controller.On("CreateApply", file.GoalClusterState, s).Return(r).Once()
+	}
+	carousel.controller = controller
+	carousel.config.DryRun = true
+	err = carousel.Resume(file.StartingColorGroup, file.TODO, file.GoalClusterState, file.Rollout.StepOptions()...)
+	assert.NoError(err)
+	mock.AssertExpectationsForObjects(t, controller)
+}
+
+// replacingController replaces the resources of the failed hosts with the next apply, like terraform apply -replace.
+// The clusters are returned in order, the last one is repeated.
+type replacingController struct {
+	controller.Tainter
+	pending  *controller.PendingReplacements
+	clusters []model.Cluster
+	// applies are the arguments of each apply run, an apply fails once failAt are run.
+	applies [][]string
+	failAt  int
+}
+
+func newReplacingController(graph map[string][]string, clusters ...model.Cluster) *replacingController {
+	pending := &controller.PendingReplacements{}
+	return &replacingController{
+		Tainter:  controller.NewReplaceTainter(hostGraph(graph), pending),
+		pending:  pending,
+		clusters: clusters,
+	}
+}
+
+func (r *replacingController) SelectWorkspace(workspace string) error {
+	return nil
+}
+
+func (r *replacingController) GetCluster() (model.Cluster, error) {
+	cluster := r.clusters[0]
+	if len(r.clusters) > 1 {
+		r.clusters = r.clusters[1:]
+	}
+	return cluster, nil
+}
+
+func (r *replacingController) CreateApply(target model.ClusterState, step model.Step) runner.Runnable {
+	flag := func(resource string) []string { return []string{"-replace=" + resource} }
+	return controller.NewReplaceApply(r.pending, flag, func(replaceArgs []string) runner.Runnable {
+		return fakeApply{controller: r, args: replaceArgs}
+	})
+}
+
+type fakeApply struct {
+	controller *replacingController
+	args       []string
+}
+
+func (f fakeApply) Output() ([]byte, error) {
+	f.controller.applies = append(f.controller.applies, f.args)
+	if f.controller.failAt > 0 && len(f.controller.applies) >= f.controller.failAt {
+		return nil, errors.New("apply failed")
+	}
+	return nil, nil
+}
+
+func (f fakeApply) String() string {
+	return strings.Join(append([]string{"apply"}, f.args...), " ")
+}
+
+type hostGraph map[string][]string
+
+func (h hostGraph) GetResourcesForHost(hostname string) ([]string, error) {
+	return h[hostname], nil
+}
+
+func TestResumeFailedReplacement(t *testing.T) {
+	assert := assert.New(t)
+	badHost := "carousel-demo-ea9412.example.com"
+	badCluster := model.Cluster{
+		model.Green: model.ClusterGroup{Hosts: []string{badHost}, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroup{},
+	}
+	graph := map[string][]string{badHost: {"module.green.aws_instance.server[0]"}}
+
+	// the apply replacing the bad host fails.
+	failing := newReplacingController(graph, emptyCluster, emptyCluster, badCluster)
+	failing.failAt = 3
+	carousel := Carousel{
+		config:     Config{Validate: func(fqdn string) bool { return fqdn != badHost }},
+		controller: failing,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+	err := carousel.Rollout(1, semver.MustParse("0.1.0"))
+	var stepError model.StepError
+	assert.ErrorAs(err, &stepError)
+	assert.Equal([][]string{{}, {}, {"-replace=module.green.aws_instance.server[0]"}}, failing.applies)
+
+	file := resume.New(stepError, time.Now(), time.Now())
+	data, err := json.Marshal(file)
+	assert.NoError(err)
+	file, err = resume.Load(data)
+	assert.NoError(err)
+	assert.Equal([]string{"module.green.aws_instance.server[0]"}, file.Replace)
+
+	// a new carousel has no pending replacements, the bad host is replaced from the resume file.
+	resumed := newReplacingController(graph, badCluster)
+	carousel.controller = resumed
+	err = carousel.ResumeFrom(file.StepError())
+	assert.NoError(err)
+	assert.Equal([][]string{{"-replace=module.green.aws_instance.server[0]"}}, resumed.applies)
+	assert.Empty(resumed.pending.List())
+}
+
+func TestResumeReconcile(t *testing.T) {
+	goalCluster := model.ClusterState{
+		model.Blue: model.ClusterGroupState{
+			Count:   2,
+			Version: semver.MustParse("0.2.0"),
+		},
+		model.Green: model.ClusterGroupState{
+			Version: semver.MustParse("0.1.0"),
+		},
+	}
+	savedSteps := []model.Step{
+		{model.Blue: 0, model.Green: 2},
+		{model.Blue: 1, model.Green: 2},
+		{model.Blue: 1, model.Green: 1},
+		{model.Blue: 2, model.Green: 1},
+		{model.Blue: 2, model.Green: 0},
+	}
+	clusterWithCounts := func(blue int, green int) model.Cluster {
+		cluster := model.Cluster{
+			model.Blue:  model.ClusterGroup{Hosts: []string{}, Version: semver.MustParse("0.2.0")},
+			model.Green: model.ClusterGroup{Hosts: []string{}, Version: semver.MustParse("0.1.0")},
+		}
+		for i := 0; i < blue; i++ {
+			cluster[model.Blue] = model.ClusterGroup{Hosts: append(cluster[model.Blue].Hosts, "blue.example.com"), Version: cluster[model.Blue].Version}
+		}
+		for i := 0; i < green; i++ {
+			cluster[model.Green] = model.ClusterGroup{Hosts: append(cluster[model.Green].Hosts, "green.example.com"), Version: cluster[model.Green].Version}
+		}
+		return cluster
+	}
+	tests := []struct {
+		name          string
+		cluster       model.Cluster
+		expectedSteps []model.Step
+	}{
+		{
+			name:          "matches_first_step",
+			cluster:       clusterWithCounts(0, 2),
+			expectedSteps: savedSteps,
+		},
+		{
+			name:          "matches_later_step",
+			cluster:       clusterWithCounts(1, 1),
+			expectedSteps: savedSteps[2:],
+		},
+		{
+			name:    "matches_no_step",
+			cluster: clusterWithCounts(2, 2),
+			expectedSteps: []model.Step{
+				{model.Blue: 2, model.Green: 2},
+				{model.Blue: 2, model.Green: 1},
+				{model.Blue: 2, model.Green: 0},
+			},
+		},
+		{
+			name:    "matches_goal",
+			cluster: clusterWithCounts(2, 0),
+		},
+		{
+			name: "matches_goal_counts_with_old_version",
+			cluster: model.Cluster{
+				model.Blue:  model.ClusterGroup{Hosts: []string{"blue.example.com", "blue.example.com"}, Version: semver.MustParse("0.1.0")},
+				model.Green: model.ClusterGroup{Hosts: []string{}, Version: semver.MustParse("0.1.0")},
+			},
+			expectedSteps: savedSteps[4:],
+		},
+		{
+			name:    "emptied_cluster",
+			cluster: clusterWithCounts(0, 0),
+			expectedSteps: []model.Step{
+				{model.Blue: 0, model.Green: 0},
+				{model.Blue: 1, model.Green: 0},
+				{model.Blue: 2, model.Green: 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			controller := &MockController{}
+			controller.On("GetCluster").Return(test.cluster, nil).Once()
+			r := &MockRunner{}
+			r.On("String").Return("mock runner")
+			for _, s := range test.expectedSteps {
+				controller.On("CreateApply", goalCluster, s).Return(r).Once()
+			}
+
+			carousel := Carousel{
+				config: Config{
+					DryRun:   true,
+					Validate: func(fqdn string) bool { return true },
+				},
+				controller: controller,
+				logger:     log.NewNopLogger(),
+				ui:         noopUI{},
+			}
+			err := carousel.Resume(model.Green, savedSteps, goalCluster)
+			assert.NoError(err)
+			controller.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResumeReconcileConfirm(t *testing.T) {
+	goalCluster := model.ClusterState{
+		model.Blue: model.ClusterGroupState{
+			Count:   2,
+			Version: semver.MustParse("0.2.0"),
+		},
+		model.Green: model.ClusterGroupState{
+			Version: semver.MustParse("0.1.0"),
+		},
+	}
+	savedSteps := []model.Step{
+		{model.Blue: 0, model.Green: 2},
+		{model.Blue: 1, model.Green: 2},
+		{model.Blue: 1, model.Green: 1},
+		{model.Blue: 2, model.Green: 1},
+		{model.Blue: 2, model.Green: 0},
+	}
+	tests := []struct {
+		name          string
+		current       model.Step
+		dryRun        bool
+		confirm       bool
+		expectedAsked int
+		expectedSteps []model.Step
+		expectedErr   error
+	}{
+		{name: "matches_first_step", current: savedSteps[0], expectedSteps: savedSteps},
+		{name: "confirmed", current: savedSteps[2], confirm: true, expectedAsked: 1, expectedSteps: savedSteps[2:]},
+		{name: "declined", current: savedSteps[2], expectedAsked: 1, expectedErr: ErrNotConfirmed},
+		{name: "dry_run", current: savedSteps[2], dryRun: true, expectedSteps: savedSteps[2:]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			asked := 0
+			carousel := Carousel{
+				config: Config{
+					DryRun: test.dryRun,
+					Confirm: func(message string) bool {
+						asked++
+						return test.confirm
+					},
+				},
+				logger: log.NewNopLogger(),
+				ui:     noopUI{},
+			}
+			current := model.ClusterState{
+				model.Blue:  model.ClusterGroupState{Count: test.current[model.Blue], Version: semver.MustParse("0.2.0")},
+				model.Green: model.ClusterGroupState{Count: test.current[model.Green], Version: semver.MustParse("0.1.0")},
+			}
+			steps, err := carousel.reconcile(current, savedSteps, goalCluster)
+			assert.Equal(test.expectedAsked, asked)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedSteps, steps)
+		})
+	}
+}
+
+type fakeRecorder struct {
+	records []history.Record
+}
+
+func (f *fakeRecorder) Record(record history.Record) error {
+	f.records = append(f.records, record)
+	return nil
+}
+
+type fakeReporter struct {
+	progress  []StepProgress
+	summaries []history.Record
+}
+
+func (f *fakeReporter) StepCompleted(progress StepProgress) {
+	f.progress = append(f.progress, progress)
+}
+
+func (f *fakeReporter) Finished(summary history.Record) {
+	f.summaries = append(f.summaries, summary)
+}
+
+type fakeOutputLog struct {
+	steps []int
+}
+
+func (f *fakeOutputLog) Step(step int) {
+	f.steps = append(f.steps, step)
+}
+
+func (f *fakeOutputLog) Dir() string {
+	return ".carousel/logs/20060102T150405Z"
+}
+
+func TestRolloutRecordsHistory(t *testing.T) {
+	assert := assert.New(t)
+	badHost := "carousel-demo-ea9412.example.com"
+	goodHost := "carousel-demo-ffdbb6.example.com"
+
+	controller := &MockController{}
+	controller.On("GetCluster").Return(emptyCluster, nil).Twice()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{Hosts: []string{badHost}, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("GetCluster").Return(model.Cluster{
+		model.Green: model.ClusterGroup{Hosts: []string{goodHost}, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroup{},
+	}, nil).Once()
+	controller.On("GetCluster").Return(emptyCluster, errors.New("state unavailable")).Once()
+	controller.On("TaintHost", badHost).Return(nil).Once()
+
+	r := &MockRunner{}
+	r.On("Output").Return([]byte("building step"), nil)
+	r.On("String").Return("mock runner")
+	controller.On("CreateApply", mock.Anything, mock.Anything).Return(r)
+
+	recorder := &fakeRecorder{}
+	reporter := &fakeReporter{}
+	outputLog := &fakeOutputLog{}
+	carousel := Carousel{
+		config: Config{
+			Validate:  func(fqdn string) bool { return fqdn != badHost },
+			Recorder:  recorder,
+			Reporter:  reporter,
+			OutputLog: outputLog,
+		},
+		controller: controller,
+		logger:     log.NewNopLogger(),
+		ui:         noopUI{},
+	}
+
+	assert.NoError(carousel.Rollout(1, semver.MustParse("0.1.0")))
+	// the cluster can't be read, so the resume fails before any step
+	assert.Error(carousel.Resume(model.Green, []model.Step{{model.Green: 1, model.Blue: 0}, {model.Green: 2, model.Blue: 0}}, model.ClusterState{
+		model.Green: model.ClusterGroupState{Count: 2, Version: semver.MustParse("0.1.0")},
+		model.Blue:  model.ClusterGroupState{},
+	}))
+
+	if assert.Len(recorder.records, 2) {
+		rollout := recorder.records[0]
+		assert.Equal(history.Rollout, rollout.Operation)
+		assert.Equal(history.Succeeded, rollout.Outcome)
+		assert.Equal([]model.Step{{model.Green: 0, model.Blue: 0}, {model.Green: 1, model.Blue: 0}}, rollout.Steps)
+		assert.Equal([]string{badHost}, rollout.TaintedHosts)
+		assert.Equal(1, rollout.To[model.Green].Count)
+		assert.False(rollout.EndedAt.Before(rollout.StartedAt))
+		assert.Equal(outputLog.Dir(), rollout.LogDir)
+
+		resume := recorder.records[1]
+		assert.Equal(history.Resume, resume.Operation)
+		assert.Equal(history.Failed, resume.Outcome)
+		assert.Empty(resume.Steps)
+		assert.NotEmpty(resume.Error)
+	}
+	assert.Equal(recorder.records, reporter.summaries)
+	// the rerun of the second step after tainting the bad host is part of the same step.
+	assert.Equal([]int{1, 2}, outputLog.steps)
+	if assert.Len(reporter.progress, 2) {
+		assert.Equal(1, reporter.progress[0].Step)
+		assert.Equal(2, reporter.progress[1].Step)
+		assert.Equal(2, reporter.progress[1].Total)
+		assert.Equal(model.Step{model.Green: 1, model.Blue: 0}, reporter.progress[1].State)
+	}
+	mock.AssertExpectationsForObjects(t, controller, r)
+}
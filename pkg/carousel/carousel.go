@@ -5,11 +5,21 @@ import (
 	"fmt"
 	"github.com/blang/semver/v4"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/xmidt-org/carousel/pkg/controller"
 	"github.com/xmidt-org/carousel/pkg/goal"
+	"github.com/xmidt-org/carousel/pkg/history"
 	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/policy"
 	"github.com/xmidt-org/carousel/pkg/step"
 	"os"
+	"strings"
+)
+
+var (
+	ErrInvalidSteps  = errors.New("steps do not meet the rollout constraints")
+	ErrVersionPolicy = errors.New("version refused by the version policy")
+	ErrNotConfirmed  = errors.New("reconciled steps not confirmed")
 )
 
 type UI interface {
@@ -41,6 +51,22 @@ type Carousel struct {
 type Config struct {
 	DryRun   bool
 	Validate HostValidator
+	// ValidateDetails checks a host with its metadata, if set it is used instead of Validate.
+	ValidateDetails HostDetailsValidator
+	// VersionPolicy is checked before rolling out a new version. A rollout only checks the version against the current
+	// cluster, the version itself must be checked with VersionPolicy.CheckVersion before the rollout.
+	VersionPolicy policy.VersionPolicy
+	// Force rolls out a version even if the VersionPolicy refuses it.
+	Force bool
+	// Recorder records the history of each operation, if set.
+	Recorder Recorder
+	// Reporter is notified of the progress of each operation, if set.
+	Reporter Reporter
+	// OutputLog keeps the output of the commands of each step, if set.
+	OutputLog OutputLog
+	// Confirm is asked before a resume applies steps reconciled with the current cluster, if set.
+	// The resume fails with ErrNotConfirmed if it returns false. A dry run never asks.
+	Confirm func(message string) bool
 }
 
 // HostValidator is a function that Checks if a Host is bad or good.
@@ -50,6 +76,14 @@ func AsHostValidator(f func(fqdn string) bool) HostValidator {
 	return f
 }
 
+// HostDetailsValidator is a function that Checks if a Host is bad or good with the metadata of the host,
+// like the ip and zone. Only the FQDN is set if the hostnames output only lists hostnames.
+type HostDetailsValidator func(host model.Host) bool
+
+func AsHostDetailsValidator(f func(host model.Host) bool) HostDetailsValidator {
+	return f
+}
+
 func NewCarousel(logger log.Logger, ui UI, controller controller.Controller, config Config) (Carousel, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -72,6 +106,11 @@ func NewCarousel(logger log.Logger, ui UI, controller controller.Controller, con
 }
 
 func (c Carousel) Rollout(nodeCount int, version semver.Version, stepOptions ...step.StepOptions) error {
+	r := c.startRun(history.Rollout)
+	return c.finishRun(r, c.rollout(r, nodeCount, version, stepOptions...))
+}
+
+func (c Carousel) rollout(r *run, nodeCount int, version semver.Version, stepOptions ...step.StepOptions) error {
 	if c.controller == nil {
 		return errors.New("controller can't be empty")
 	}
@@ -80,27 +119,183 @@ func (c Carousel) Rollout(nodeCount int, version semver.Version, stepOptions ...
 	if err != nil {
 		return fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
 	}
+	r.from(cc.AsClusterState())
+	// Check the version can be rolled out to the current cluster.
+	if err := c.checkVersion(c.config.VersionPolicy.CheckCurrent(cc.AsClusterState(), version), version); err != nil {
+		return err
+	}
 	// Determine the goal state.
 	goalCluster, err := goal.BuildEndState(cc.AsClusterState(), nodeCount, version)
 	if err != nil {
 		return fmt.Errorf("%w: %v", controller.ErrGoalStateFailure, err)
 	}
+	r.to(goalCluster)
+
+	return c.rolloutTo(r, cc, goalCluster, stepOptions...)
+}
+
+// Apply transitions the cluster to the DesiredState.
+// Nothing is done if the cluster already matches, and the VersionPolicy is only checked when the version changes.
+func (c Carousel) Apply(desired model.DesiredState, stepOptions ...step.StepOptions) error {
+	r := c.startRun(history.Apply)
+	return c.finishRun(r, c.apply(r, desired, stepOptions...))
+}
+
+func (c Carousel) apply(r *run, desired model.DesiredState, stepOptions ...step.StepOptions) error {
+	if c.controller == nil {
+		return errors.New("controller can't be empty")
+	}
+	// Get the current cluster.
+	cc, err := c.controller.GetCluster()
+	if err != nil {
+		return fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
+	}
+	current := cc.AsClusterState()
+	r.from(current)
+	// Determine the goal state.
+	goalCluster, err := goal.BuildDesiredState(current, desired.Group, desired.Count, desired.Version)
+	if err != nil {
+		return fmt.Errorf("%w: %v", controller.ErrGoalStateFailure, err)
+	}
+	r.to(goalCluster)
+	if current.Equal(goalCluster) {
+		c.ui.Info("cluster already matches the desired state")
+		r.skip()
+		return nil
+	}
+	// Check the version can be rolled out, if only the node count changes there is nothing to check.
+	if currentGroup, _ := current.Group(); current[currentGroup].Count == 0 || !current[currentGroup].Version.EQ(desired.Version) {
+		if err := c.checkVersion(c.config.VersionPolicy.Check(current, desired.Version), desired.Version); err != nil {
+			return err
+		}
+	}
+
+	return c.rolloutTo(r, cc, goalCluster, stepOptions...)
+}
+
+// checkVersion fails on the violations of the VersionPolicy, they are only logged if Force is set.
+func (c Carousel) checkVersion(violations error, version semver.Version) error {
+	if violations == nil {
+		return nil
+	}
+	if !c.config.Force {
+		return fmt.Errorf("%w: %v", ErrVersionPolicy, violations)
+	}
+	level.Warn(c.logger).Log("msg", "version policy overridden", "version", version, "violations", violations)
+	return nil
+}
+
+// rolloutTo builds and validates the steps from the current cluster to the goal and applies them.
+func (c Carousel) rolloutTo(r *run, cc model.Cluster, goalCluster model.ClusterState, stepOptions ...step.StepOptions) error {
 	currentGroup, _ := cc.AsClusterState().Group()
 
 	// Build the steps to get to goal
 	steps := step.CreateSteps(cc.AsClusterState(), goalCluster, stepOptions...)
+	if err := step.Validate(steps, goalCluster, stepOptions...); err != nil {
+		c.ui.Warn(fmt.Sprintf("generated steps do not meet the rollout constraints: %v", err))
+	}
 
-	return c.transition(cc, currentGroup, steps, goalCluster)
+	return c.transition(r, cc, currentGroup, steps, goalCluster)
 }
 
-func (c Carousel) Resume(startingColor model.Color, steps []model.Step, goalCluster model.ClusterState) error {
+// Resume continues a failed transition with the remaining steps.
+// The steps are rejected if they do not meet the constraints of the given StepOptions.
+// If the current cluster drifted from the first step, the steps are reconciled with the current cluster (see reconcile).
+func (c Carousel) Resume(startingColor model.Color, steps []model.Step, goalCluster model.ClusterState, stepOptions ...step.StepOptions) error {
+	return c.ResumeFrom(model.StepError{
+		TODO:               steps,
+		StartingColorGroup: startingColor,
+		GoalClusterState:   goalCluster,
+	}, stepOptions...)
+}
+
+// ResumeFrom continues the failed transition of a StepError like Resume.
+// The resources the failed apply was replacing are replaced by the next apply.
+func (c Carousel) ResumeFrom(stepError model.StepError, stepOptions ...step.StepOptions) error {
+	r := c.startRun(history.Resume)
+	r.to(stepError.GoalClusterState)
+	return c.finishRun(r, c.resume(r, stepError, stepOptions...))
+}
+
+func (c Carousel) resume(r *run, stepError model.StepError, stepOptions ...step.StepOptions) error {
 	if c.controller == nil {
 		return errors.New("controller can't be empty")
 	}
+	steps, goalCluster := stepError.TODO, stepError.GoalClusterState
+	if err := step.Validate(steps, goalCluster, stepOptions...); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSteps, err)
+	}
 	// Get the current cluster.
 	cc, err := c.controller.GetCluster()
 	if err != nil {
 		return fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
 	}
-	return c.transition(cc, startingColor, steps, goalCluster)
+	r.from(cc.AsClusterState())
+	if len(stepError.Replace) > 0 {
+		if err := c.replace(stepError.Replace); err != nil {
+			return err
+		}
+	}
+	if !cc.AsClusterState().IsEmpty() && cc.AsClusterState().Equal(goalCluster) {
+		if len(stepError.Replace) == 0 {
+			c.ui.Info("cluster already matches the goal state")
+			r.skip()
+			return nil
+		}
+		// the goal is applied again to replace the resources.
+		steps = []model.Step{step.AsStep(goalCluster)}
+	}
+	steps, err = c.reconcile(cc.AsClusterState(), steps, goalCluster, stepOptions...)
+	if err != nil {
+		return err
+	}
+	return c.transition(r, cc, stepError.StartingColorGroup, steps, goalCluster)
+}
+
+// replace marks the resources to be replaced by the next apply, a dry run only shows them.
+func (c Carousel) replace(resources []string) error {
+	c.ui.Warn(fmt.Sprintf("the failed apply was replacing %s, they are replaced again", strings.Join(resources, ", ")))
+	if c.config.DryRun {
+		return nil
+	}
+	if err := c.controller.TaintResources(resources); err != nil {
+		return err
+	}
+	c.invalidate()
+	return nil
+}
+
+// reconcile returns the steps to run from the current cluster.
+// If the cluster matches one of the steps, the steps from the last match are used.
+// Otherwise the steps to the goal are generated again from the current cluster.
+// The reconciled steps are shown if they differ from the given steps, and only applied once confirmed.
+func (c Carousel) reconcile(current model.ClusterState, steps []model.Step, goalCluster model.ClusterState, stepOptions ...step.StepOptions) ([]model.Step, error) {
+	if current.EqualStep(steps[0]) {
+		return steps, nil
+	}
+
+	var reconciled []model.Step
+	for index := len(steps) - 1; index > 0; index-- {
+		if current.EqualStep(steps[index]) {
+			reconciled = steps[index:]
+			c.ui.Warn(fmt.Sprintf("current cluster matches step %d, skipping the previous steps", index))
+			break
+		}
+	}
+	if reconciled == nil {
+		reconciled = step.CreateSteps(current, goalCluster, stepOptions...)
+		c.ui.Warn(fmt.Sprintf("current cluster %s doesn't match any step, building new steps to the goal", current))
+		if err := step.Validate(reconciled, goalCluster, stepOptions...); err != nil {
+			c.ui.Warn(fmt.Sprintf("generated steps do not meet the rollout constraints: %v", err))
+		}
+	}
+
+	c.ui.Info("reconciled steps:")
+	for _, s := range reconciled {
+		c.ui.Info(fmt.Sprintf("  %s", describeStep(s)))
+	}
+	if !c.config.DryRun && c.config.Confirm != nil && !c.config.Confirm("Do you want to apply the reconciled steps?") {
+		return nil, ErrNotConfirmed
+	}
+	return reconciled, nil
 }
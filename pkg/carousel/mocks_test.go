@@ -35,6 +35,14 @@ func (m *MockController) TaintHost(hostname string) error {
 	return args.Error(0)
 }
 
+type MockCachingController struct {
+	MockController
+}
+
+func (m *MockCachingController) Invalidate() {
+	m.Called()
+}
+
 type MockRunner struct {
 	mock.Mock
 }
@@ -0,0 +1,127 @@
+package carousel
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/carousel/pkg/history"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+// Recorder records the history of each rollout, resume and apply.
+type Recorder interface {
+	Record(record history.Record) error
+}
+
+// Reporter is notified of the progress of each rollout, resume and apply.
+type Reporter interface {
+	// StepCompleted is called after each step is applied.
+	StepCompleted(progress StepProgress)
+	// Finished is called with the summary of the operation, including dry runs.
+	Finished(summary history.Record)
+}
+
+// OutputLog keeps the output of the commands run by each step of an operation.
+type OutputLog interface {
+	// Step is called before each step is applied, starting at 1.
+	Step(step int)
+	// Dir is the directory of the output, recorded in the history.
+	Dir() string
+}
+
+// StepProgress is the progress of an operation after a step is applied.
+type StepProgress struct {
+	Operation history.Operation `json:"operation"`
+	// Step is the number of the step applied, starting at 1.
+	Step  int        `json:"step"`
+	Total int        `json:"total"`
+	State model.Step `json:"state"`
+	Time  time.Time  `json:"time"`
+}
+
+// run tracks the progress of an operation for its history.Record.
+// Hosts are tainted concurrently, so the record is guarded by a mutex.
+type run struct {
+	lock    sync.Mutex
+	record  history.Record
+	skipped bool
+}
+
+func (c Carousel) startRun(operation history.Operation) *run {
+	return &run{
+		record: history.Record{
+			Operation: operation,
+			StartedAt: time.Now().UTC(),
+			Steps:     []model.Step{},
+		},
+	}
+}
+
+// finishRun reports the summary of the run, records it with the Recorder and returns err.
+// Nothing is recorded for a dry run or if the cluster already matched the goal.
+func (c Carousel) finishRun(r *run, err error) error {
+	r.lock.Lock()
+	record := r.record
+	skipped := r.skipped
+	r.lock.Unlock()
+
+	record.EndedAt = time.Now().UTC()
+	if c.config.OutputLog != nil {
+		record.LogDir = c.config.OutputLog.Dir()
+	}
+	record.Outcome = history.Succeeded
+	if err != nil {
+		record.Outcome = history.Failed
+		record.Error = err.Error()
+	}
+	if c.config.Reporter != nil {
+		c.config.Reporter.Finished(record)
+	}
+	if c.config.Recorder == nil || c.config.DryRun || skipped {
+		return err
+	}
+	if recordErr := c.config.Recorder.Record(record); recordErr != nil {
+		c.ui.Warn(fmt.Sprintf("failed to record history: %v", recordErr))
+	}
+	return err
+}
+
+func (r *run) from(current model.ClusterState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record.From = current
+}
+
+func (r *run) to(goal model.ClusterState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record.To = goal
+}
+
+// completed adds the step to the run and returns its progress.
+func (r *run) completed(step model.Step, index int, total int) StepProgress {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record.Steps = append(r.record.Steps, step)
+	return StepProgress{
+		Operation: r.record.Operation,
+		Step:      index + 1,
+		Total:     total,
+		State:     step,
+		Time:      time.Now().UTC(),
+	}
+}
+
+func (r *run) tainted(host string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record.TaintedHosts = append(r.record.TaintedHosts, host)
+}
+
+// skip marks the run as having nothing to do.
+func (r *run) skip() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.skipped = true
+}
@@ -9,6 +9,8 @@ import (
 var (
 	ErrGetClusterFailure = errors.New("failed to get cluster state")
 	ErrGoalStateFailure  = errors.New("failed to establish goal state")
+	// ErrWorkspaceNotFound is returned for a workspace that doesn't exist and isn't created.
+	ErrWorkspaceNotFound = errors.New("workspace not found")
 )
 
 // WorkspaceSelecter is something that can change the workspace.
@@ -18,12 +20,32 @@ type WorkspaceSelecter interface {
 	SelectWorkspace(workspace string) error
 }
 
+// WorkspaceManager is something that can manage the workspaces.
+type WorkspaceManager interface {
+	WorkspaceSelecter
+	// ListWorkspaces returns the names of the workspaces.
+	ListWorkspaces() ([]string, error)
+	// CurrentWorkspace returns the name of the workspace used.
+	CurrentWorkspace() (string, error)
+	// NewWorkspace creates the workspace and changes to it.
+	NewWorkspace(workspace string) error
+	// DeleteWorkspace deletes the workspace, force deletes it even if it still manages resources.
+	DeleteWorkspace(workspace string, force bool) error
+}
+
 // WorkspaceSelecter is something that can change get the current Cluster.
 type ClusterGetter interface {
 	// GetCluster returns the cluster or an error.
 	GetCluster() (model.Cluster, error)
 }
 
+// Cache is something that caches the cluster between calls.
+type Cache interface {
+	// Invalidate drops the cached cluster, so the next call reads it again.
+	// It must be called after something changes the cluster, like an apply or a taint.
+	Invalidate()
+}
+
 // Tainter is something that can mark something as bad.
 type Tainter interface {
 	// TaintResources will mark the given resources as bad or returns an error.
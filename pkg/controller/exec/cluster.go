@@ -0,0 +1,80 @@
+package exec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"os"
+)
+
+var (
+	errFailedToGetCluster = errors.New("failed to run getCluster")
+	errReadClusterFile    = errors.New("failed to read cluster file")
+	errBuildCluster       = errors.New("failed to read cluster")
+)
+
+// eCluster reads the cluster printed by the GetCluster command.
+type eCluster struct {
+	clusterRunner runner.Runnable
+}
+
+func (e *eCluster) GetCluster() (model.Cluster, error) {
+	data, err := e.clusterRunner.Output()
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errFailedToGetCluster, err)
+	}
+	return readCluster(data)
+}
+
+// eClusterFile reads the cluster from a file in the model.Cluster format instead of running GetCluster.
+type eClusterFile struct {
+	path string
+}
+
+func (e *eClusterFile) GetCluster() (model.Cluster, error) {
+	data, err := os.ReadFile(e.path)
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errReadClusterFile, err)
+	}
+	return readCluster(data)
+}
+
+// readCluster reads a cluster in the model.Cluster format, the groups missing from it have no hosts.
+// An unknown group fails as the model.Color can't be read.
+func readCluster(data []byte) (model.Cluster, error) {
+	c := model.NewCluster()
+	read := model.Cluster{}
+	if err := json.Unmarshal(data, &read); err != nil {
+		return c, fmt.Errorf("%w: %v", errBuildCluster, err)
+	}
+	for color, group := range read {
+		if group.Hosts == nil {
+			group.Hosts = make([]string, 0)
+		}
+		if len(group.Details) > 0 && len(group.Details) != len(group.Hosts) {
+			return c, fmt.Errorf("%w: %s has %d details for %d hosts", errBuildCluster, color, len(group.Details), len(group.Hosts))
+		}
+		c[color] = group
+	}
+	return c, nil
+}
+
+// BuildStateDeterminer builds a ClusterGetter running the GetCluster command.
+func BuildStateDeterminer(config model.BinaryConfig, execConfig Config) (controller.ClusterGetter, error) {
+	getCluster, err := parseCommand("getCluster", execConfig.GetCluster)
+	if err != nil {
+		return nil, err
+	}
+	return &eCluster{
+		clusterRunner: getCluster.build(config, runner.Options{}.WithSuppressErrOutput(true), nil, nil),
+	}, nil
+}
+
+// BuildClusterFileDeterminer builds a ClusterGetter reading a file in the model.Cluster format, e.g. the output of
+// getCluster or carousel state --full --json. The command is never run.
+func BuildClusterFileDeterminer(path string) controller.ClusterGetter {
+	return &eClusterFile{path: path}
+}
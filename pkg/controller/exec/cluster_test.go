@@ -0,0 +1,89 @@
+package exec
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const cluster = `{
+  "blue": {"hosts": ["blue-0.example.com"], "version": "1.0.0"},
+  "green": {
+    "hosts": ["green-0.example.com"],
+    "version": "1.1.0",
+    "details": [{"fqdn": "green-0.example.com", "ip": "10.0.0.1"}]
+  }
+}`
+
+func TestGetCluster(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(dir, "cluster.json"), []byte(cluster), 0600))
+	getter, err := BuildStateDeterminer(model.BinaryConfig{WorkingDirectory: dir}, Config{GetCluster: []string{"cat", "cluster.json"}})
+	assert.NoError(err)
+	c, err := getter.GetCluster()
+	assert.NoError(err)
+	assert.Equal(model.Cluster{
+		model.Blue: model.ClusterGroup{Hosts: []string{"blue-0.example.com"}, Version: semver.MustParse("1.0.0")},
+		model.Green: model.ClusterGroup{
+			Hosts:   []string{"green-0.example.com"},
+			Version: semver.MustParse("1.1.0"),
+			Details: []model.Host{{FQDN: "green-0.example.com", IP: "10.0.0.1"}},
+		},
+	}, c)
+
+	fileCluster, err := BuildClusterFileDeterminer(filepath.Join(dir, "cluster.json")).GetCluster()
+	assert.NoError(err)
+	assert.Equal(c, fileCluster)
+}
+
+func TestGetClusterFailure(t *testing.T) {
+	getter, err := BuildStateDeterminer(model.BinaryConfig{}, Config{GetCluster: []string{"sh", "-c", "exit 1"}})
+	assert.NoError(t, err)
+	_, err = getter.GetCluster()
+	assert.True(t, errors.Is(err, errFailedToGetCluster), err)
+}
+
+func TestReadCluster(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		expectedBlue model.ClusterGroup
+		expectedErr  error
+	}{
+		{
+			name:         "missing_group",
+			data:         `{"green": {"hosts": ["green-0.example.com"], "version": "1.1.0"}}`,
+			expectedBlue: model.ClusterGroup{Hosts: []string{}},
+		},
+		{
+			name:         "no_hosts",
+			data:         `{"blue": {"version": "1.0.0"}}`,
+			expectedBlue: model.ClusterGroup{Hosts: []string{}, Version: semver.MustParse("1.0.0")},
+		},
+		{name: "unknown_group", data: `{"red": {"hosts": []}}`, expectedErr: errBuildCluster},
+		{name: "bad_version", data: `{"blue": {"version": "one"}}`, expectedErr: errBuildCluster},
+		{name: "not_json", data: `blue-0.example.com`, expectedErr: errBuildCluster},
+		{
+			name:        "details_mismatch",
+			data:        `{"blue": {"hosts": ["a", "b"], "details": [{"fqdn": "a"}]}}`,
+			expectedErr: errBuildCluster,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			c, err := readCluster([]byte(test.data))
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedBlue, c[model.Blue])
+		})
+	}
+}
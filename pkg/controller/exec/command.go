@@ -0,0 +1,124 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
+	"text/template"
+)
+
+var (
+	errInvalidConfig   = errors.New("invalid exec config")
+	errInvalidCommand  = errors.New("invalid command template")
+	errMissingCommand  = errors.New("command not configured")
+	errTemplateFailure = errors.New("failed to build command")
+)
+
+// envPrefix prefixes the environment variables given to each command.
+const envPrefix = "CAROUSEL_"
+
+// Config configures the commands run by the exec controller. Each command is the binary followed by its arguments,
+// e.g. ["./cluster.sh", "apply", "--blue={{.Counts.blue}}"]. The binary and arguments are go templates.
+type Config struct {
+	// GetCluster prints the cluster as JSON in the model.Cluster format to stdout,
+	// like {"blue": {"hosts": ["a.example.com"], "version": "1.0.0"}}.
+	GetCluster []string
+
+	// ApplyStep changes the cluster to the counts and versions of a step. .Counts and .Versions map each group name to
+	// its count and version, they are also given as the CAROUSEL_<GROUP>_COUNT and CAROUSEL_<GROUP>_VERSION
+	// environment variables.
+	ApplyStep []string
+
+	// TaintHost marks a host failing validation so the next ApplyStep replaces it. .Host is the fqdn of the host,
+	// it is also given as CAROUSEL_HOST.
+	// (Optional): without it a failing host fails the rollout.
+	TaintHost []string
+
+	// SelectWorkspace changes the workspace of the other commands. .Workspace is the workspace, it is also given as
+	// CAROUSEL_WORKSPACE.
+	// (Optional): without it a workspace can't be configured.
+	SelectWorkspace []string
+}
+
+// Validate checks the required commands are configured and every command can be parsed.
+func (c Config) Validate() error {
+	if len(c.GetCluster) == 0 {
+		return fmt.Errorf("%w: getCluster is required", errInvalidConfig)
+	}
+	if len(c.ApplyStep) == 0 {
+		return fmt.Errorf("%w: applyStep is required", errInvalidConfig)
+	}
+	for name, args := range map[string][]string{
+		"getCluster":      c.GetCluster,
+		"applyStep":       c.ApplyStep,
+		"taintHost":       c.TaintHost,
+		"selectWorkspace": c.SelectWorkspace,
+	} {
+		if _, err := parseCommand(name, args); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidConfig, err)
+		}
+	}
+	return nil
+}
+
+// command is a parsed command of the Config, without templates if it isn't configured.
+type command struct {
+	name      string
+	templates []*template.Template
+}
+
+func parseCommand(name string, args []string) (*command, error) {
+	c := &command{name: name}
+	for i, arg := range args {
+		// a missing key is an error, so a typo in a group name doesn't apply an empty count.
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", name, i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidCommand, err)
+		}
+		c.templates = append(c.templates, tmpl)
+	}
+	return c, nil
+}
+
+// build builds the Runnable of the command with the template data, the environment is added on top of the
+// Environment of the config. A command that isn't configured fails with errMissingCommand when it runs.
+func (c *command) build(config model.BinaryConfig, options runner.Options, data interface{}, environment []model.ValuePair) runner.Runnable {
+	if len(c.templates) == 0 {
+		return failedRunnable{err: fmt.Errorf("%w: %s", errMissingCommand, c.name)}
+	}
+	args := make([]string, 0, len(c.templates))
+	for _, tmpl := range c.templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return failedRunnable{err: fmt.Errorf("%w: %v", errTemplateFailure, err)}
+		}
+		args = append(args, buf.String())
+	}
+	r := runner.WithLogName(runner.NewCMDRunner(config.WorkingDirectory, args[0], options, args[1:]...), c.name)
+	// the args and private args are given as environment variables, so secrets are never part of the command.
+	r = runner.AddVisibleEnvironment(r, "", config.Args)
+	r = runner.AddEnvironment(r, "", config.PrivateArgs)
+	r = runner.AddEnvironment(r, "", config.Environment)
+	return runner.AddVisibleEnvironment(r, envPrefix, environment)
+}
+
+// failedRunnable fails without running anything, for a command that couldn't be built.
+type failedRunnable struct {
+	err error
+}
+
+func (f failedRunnable) Output() ([]byte, error) {
+	return nil, f.err
+}
+
+func (f failedRunnable) String() string {
+	return f.err.Error()
+}
+
+// envName converts a group name into an environment variable name, canary-east becomes CANARY_EAST.
+func envName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
@@ -0,0 +1,95 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		expectedErr error
+	}{
+		{
+			name:   "required",
+			config: Config{GetCluster: []string{"./cluster.sh", "get"}, ApplyStep: []string{"./cluster.sh", "apply"}},
+		},
+		{
+			name: "all",
+			config: Config{
+				GetCluster:      []string{"./cluster.sh", "get"},
+				ApplyStep:       []string{"./cluster.sh", "apply", "--blue={{.Counts.blue}}"},
+				TaintHost:       []string{"./cluster.sh", "taint", "{{.Host}}"},
+				SelectWorkspace: []string{"./cluster.sh", "select", "{{.Workspace}}"},
+			},
+		},
+		{name: "no_get_cluster", config: Config{ApplyStep: []string{"apply"}}, expectedErr: errInvalidConfig},
+		{name: "no_apply_step", config: Config{GetCluster: []string{"get"}}, expectedErr: errInvalidConfig},
+		{
+			name:        "bad_template",
+			config:      Config{GetCluster: []string{"get"}, ApplyStep: []string{"apply", "{{.Counts.blue"}},
+			expectedErr: errInvalidConfig,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCommandBuild(t *testing.T) {
+	config := model.BinaryConfig{
+		Args:        []model.ValuePair{{Key: "REGION", Value: "us-east-1"}},
+		PrivateArgs: []model.ValuePair{{Key: "TOKEN", Value: "hunter2"}},
+	}
+	tests := []struct {
+		name           string
+		args           []string
+		data           interface{}
+		environment    []model.ValuePair
+		expectedString string
+		expectedErr    error
+	}{
+		{
+			name:           "template",
+			args:           []string{"./cluster.sh", "taint", "{{.Host}}"},
+			data:           hostData{Host: "a.example.com"},
+			environment:    []model.ValuePair{{Key: "HOST", Value: "a.example.com"}},
+			expectedString: "CAROUSEL_HOST=a.example.com TOKEN=xxxx REGION=us-east-1 ./cluster.sh taint a.example.com",
+		},
+		{
+			name:        "missing_key",
+			args:        []string{"./cluster.sh", "{{.Counts.red}}"},
+			data:        stepData{Counts: map[string]int{"blue": 1}},
+			expectedErr: errTemplateFailure,
+		},
+		{
+			name:        "not_configured",
+			expectedErr: errMissingCommand,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			c, err := parseCommand("test", test.args)
+			assert.NoError(err)
+			r := c.build(config, runner.Options{}, test.data, test.environment)
+			if test.expectedErr != nil {
+				_, err := r.Output()
+				assert.True(errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.Equal(test.expectedString, r.String())
+		})
+	}
+}
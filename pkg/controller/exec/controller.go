@@ -0,0 +1,50 @@
+package exec
+
+import (
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+// BuildController builds a controller.Controller running the commands of the Config.
+// The Config is validated first, an error is returned if a command is missing or can't be parsed.
+func BuildController(config model.BinaryConfig, execConfig Config, transitionConfig ExecTransitionConfig) (controller.Controller, error) {
+	if err := execConfig.Validate(); err != nil {
+		return nil, err
+	}
+	// Validate parsed every command, so they can't fail.
+	applyStep, _ := parseCommand("applyStep", execConfig.ApplyStep)
+	taintHost, _ := parseCommand("taintHost", execConfig.TaintHost)
+	clusterGetter, _ := BuildStateDeterminer(config, execConfig)
+
+	return struct {
+		controller.WorkspaceSelecter
+		controller.ClusterGetter
+		controller.Tainter
+		controller.ApplyBuilder
+	}{
+		WorkspaceSelecter: BuildSelectWorkspaceRunner(config, execConfig),
+		ClusterGetter:     clusterGetter,
+		Tainter:           &eTaint{config: config, taintHost: taintHost},
+		ApplyBuilder: &eTransition{
+			config:           config,
+			transitionConfig: transitionConfig,
+			applyStep:        applyStep,
+		},
+	}, nil
+}
+
+// BuildSelectWorkspaceRunner builds a WorkspaceSelecter running the SelectWorkspace command.
+// Without the command only the current workspace, an empty workspace, can be selected.
+func BuildSelectWorkspaceRunner(config model.BinaryConfig, execConfig Config) controller.WorkspaceSelecter {
+	selectWorkspace, _ := parseCommand("selectWorkspace", execConfig.SelectWorkspace)
+	return &eSelectWorkspace{config: config, selectWorkspace: selectWorkspace}
+}
+
+// BuildTaintHostRunner builds a Tainter running the TaintHost command.
+func BuildTaintHostRunner(config model.BinaryConfig, execConfig Config) (controller.Tainter, error) {
+	taintHost, err := parseCommand("taintHost", execConfig.TaintHost)
+	if err != nil {
+		return nil, err
+	}
+	return &eTaint{config: config, taintHost: taintHost}, nil
+}
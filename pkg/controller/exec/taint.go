@@ -0,0 +1,40 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+)
+
+var errTaintHostFailure = errors.New("failed to taint host")
+
+// hostData is the template data of the TaintHost command.
+type hostData struct {
+	// Host is the fqdn of the host.
+	Host string
+}
+
+// eTaint runs the TaintHost command for each host failing validation.
+type eTaint struct {
+	config    model.BinaryConfig
+	taintHost *command
+}
+
+// TaintResources runs the TaintHost command for each resource, the exec controller only knows hosts.
+func (e *eTaint) TaintResources(resources []string) error {
+	for _, resource := range resources {
+		if err := e.TaintHost(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *eTaint) TaintHost(hostname string) error {
+	environment := []model.ValuePair{{Key: "HOST", Value: hostname}}
+	if _, err := e.taintHost.build(e.config, runner.Options{}, hostData{Host: hostname}, environment).Output(); err != nil {
+		return fmt.Errorf("%w: %v for host %s", errTaintHostFailure, err, hostname)
+	}
+	return nil
+}
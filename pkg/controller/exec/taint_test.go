@@ -0,0 +1,51 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTaintHost(t *testing.T) {
+	tests := []struct {
+		name          string
+		taintHost     []string
+		expectedTaint string
+		expectedErr   error
+	}{
+		{
+			name:          "taint",
+			taintHost:     []string{"sh", "-c", `echo "$0 $CAROUSEL_HOST" >> tainted.txt`, "{{.Host}}"},
+			expectedTaint: "a.example.com a.example.com\nb.example.com b.example.com\n",
+		},
+		{
+			name:        "failure",
+			taintHost:   []string{"sh", "-c", "exit 1"},
+			expectedErr: errTaintHostFailure,
+		},
+		{
+			name:        "not_configured",
+			expectedErr: errTaintHostFailure,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			dir := t.TempDir()
+			tainter, err := BuildTaintHostRunner(model.BinaryConfig{WorkingDirectory: dir}, Config{TaintHost: test.taintHost})
+			assert.NoError(err)
+			err = tainter.TaintResources([]string{"a.example.com", "b.example.com"})
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(err)
+			data, err := os.ReadFile(filepath.Join(dir, "tainted.txt"))
+			assert.NoError(err)
+			assert.Equal(test.expectedTaint, string(data))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strconv"
+)
+
+// stepData is the template data of the ApplyStep command.
+type stepData struct {
+	// Counts maps each group name to the number of nodes of the step.
+	Counts map[string]int
+	// Versions maps each group name to the version of the target.
+	Versions map[string]string
+}
+
+// eTransition is an exec specific implementation of Transition, running the ApplyStep command for each step.
+type eTransition struct {
+	config           model.BinaryConfig
+	transitionConfig ExecTransitionConfig
+	applyStep        *command
+}
+
+func (e *eTransition) CreateApply(target model.ClusterState, step model.Step) runner.Runnable {
+	data := stepData{
+		Counts:   make(map[string]int),
+		Versions: make(map[string]string),
+	}
+	environment := make([]model.ValuePair, 0, 2*len(model.ValidColors))
+	for _, color := range model.ValidColors {
+		data.Counts[color.String()] = step[color]
+		data.Versions[color.String()] = target[color].Version.String()
+		environment = append(environment,
+			model.ValuePair{Key: envName(color.String()) + "_COUNT", Value: strconv.Itoa(step[color])},
+			model.ValuePair{Key: envName(color.String()) + "_VERSION", Value: target[color].Version.String()},
+		)
+	}
+	return e.applyStep.build(e.config, runner.Options{
+		ShowOutput:        e.transitionConfig.AttachStdOut,
+		SuppressErrOutput: !e.transitionConfig.AttachStdErr,
+	}, data, environment)
+}
+
+type ExecTransitionConfig struct {
+	AttachStdOut bool
+	AttachStdErr bool
+}
@@ -0,0 +1,39 @@
+package exec
+
+import (
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateApply(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	execConfig := Config{
+		GetCluster: []string{"cat", "cluster.json"},
+		// the step is written as args and from the environment.
+		ApplyStep: []string{"sh", "-c", `echo "$0 $1 $CAROUSEL_BLUE_COUNT $CAROUSEL_BLUE_VERSION $CAROUSEL_GREEN_COUNT" > step.txt`,
+			"{{.Counts.blue}}", "{{.Versions.green}}"},
+	}
+	c, err := BuildController(model.BinaryConfig{WorkingDirectory: dir}, execConfig, ExecTransitionConfig{})
+	assert.NoError(err)
+	apply := c.CreateApply(model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("1.2.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("1.1.0")},
+	}, model.Step{model.Blue: 2, model.Green: 1})
+	assert.Contains(apply.String(), "CAROUSEL_BLUE_COUNT=2 CAROUSEL_BLUE_VERSION=1.2.0 CAROUSEL_GREEN_COUNT=1 CAROUSEL_GREEN_VERSION=1.1.0 sh -c")
+
+	_, err = apply.Output()
+	assert.NoError(err)
+	data, err := os.ReadFile(filepath.Join(dir, "step.txt"))
+	assert.NoError(err)
+	assert.Equal("2 1.1.0 2 1.2.0 1\n", string(data))
+}
+
+func TestBuildControllerInvalid(t *testing.T) {
+	_, err := BuildController(model.BinaryConfig{}, Config{GetCluster: []string{"cat"}}, ExecTransitionConfig{})
+	assert.ErrorIs(t, err, errInvalidConfig)
+}
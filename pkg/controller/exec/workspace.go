@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+)
+
+var errSelectWorkspaceFailure = errors.New("failed to select workspace")
+
+// workspaceData is the template data of the SelectWorkspace command.
+type workspaceData struct {
+	Workspace string
+}
+
+// eSelectWorkspace runs the SelectWorkspace command.
+type eSelectWorkspace struct {
+	config          model.BinaryConfig
+	selectWorkspace *command
+}
+
+func (e *eSelectWorkspace) SelectWorkspace(workspace string) error {
+	if workspace == "" {
+		// if workspace is empty use what is currently selected
+		return nil
+	}
+	environment := []model.ValuePair{{Key: "WORKSPACE", Value: workspace}}
+	if _, err := e.selectWorkspace.build(e.config, runner.Options{}, workspaceData{Workspace: workspace}, environment).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errSelectWorkspaceFailure, err, workspace)
+	}
+	return nil
+}
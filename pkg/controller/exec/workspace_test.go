@@ -0,0 +1,54 @@
+package exec
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectWorkspace(t *testing.T) {
+	tests := []struct {
+		name              string
+		selectWorkspace   []string
+		workspace         string
+		expectedWorkspace string
+		expectedErr       error
+	}{
+		{
+			name:              "select",
+			selectWorkspace:   []string{"sh", "-c", `echo "$0 $CAROUSEL_WORKSPACE" > workspace.txt`, "{{.Workspace}}"},
+			workspace:         "prod",
+			expectedWorkspace: "prod prod\n",
+		},
+		{
+			name:      "current_without_command",
+			workspace: "",
+		},
+		{
+			name:        "not_configured",
+			workspace:   "prod",
+			expectedErr: errMissingCommand,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			dir := t.TempDir()
+			selecter := BuildSelectWorkspaceRunner(model.BinaryConfig{WorkingDirectory: dir}, Config{SelectWorkspace: test.selectWorkspace})
+			err := selecter.SelectWorkspace(test.workspace)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(err)
+			if test.expectedWorkspace != "" {
+				data, err := os.ReadFile(filepath.Join(dir, "workspace.txt"))
+				assert.NoError(err)
+				assert.Equal(test.expectedWorkspace, string(data))
+			}
+		})
+	}
+}
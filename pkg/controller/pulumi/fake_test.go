@@ -0,0 +1,57 @@
+package pulumi
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	stacks = `[
+  {"name": "default", "current": true},
+  {"name": "prod-east", "current": false}
+]`
+	outputs = `{
+  "blueHostnames": ["blue-0.example.com", "blue-1.example.com"],
+  "blueVersion": "1.0.0",
+  "greenHostnames": {
+    "a": {"fqdn": "green-a.example.com", "ip": "10.0.0.1", "address": "urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-a"},
+    "b": {"fqdn": "green-b.example.com", "ip": "10.0.0.2"}
+  },
+  "greenVersion": "1.1.0"
+}`
+	export = `{
+  "version": 3,
+  "deployment": {
+    "resources": [
+      {"urn": "urn:pulumi:default::cluster::pulumi:pulumi:Stack::cluster-default", "custom": false, "outputs": {"greenHostnames": "green-b.example.com"}},
+      {"urn": "urn:pulumi:default::cluster::aws:ec2/instance:Instance::blue-1", "custom": true, "outputs": {"publicDns": "blue-1.example.com"}},
+      {"urn": "urn:pulumi:default::cluster::aws:route53/record:Record::blue-1", "custom": true, "outputs": {"fqdn": "blue-1.example.com"}},
+      {"urn": "urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-b", "custom": true, "outputs": {"privateIp": "10.0.0.2"}}
+    ]
+  }
+}`
+)
+
+// fakePulumi points the BinaryConfig at the fake pulumi binary of testdata, reading its output from a temporary directory.
+// The calls made to the binary are returned by the calls function.
+func fakePulumi(t *testing.T) (config model.BinaryConfig, calls func() []string) {
+	t.Helper()
+	binary, err := filepath.Abs(filepath.Join("testdata", "pulumi"))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	t.Setenv("FAKE_PULUMI_DIR", dir)
+	for name, data := range map[string]string{"stacks.json": stacks, "outputs.json": outputs, "export.json": export} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0600))
+	}
+	return model.BinaryConfig{Binary: binary, WorkingDirectory: dir}, func() []string {
+		data, err := os.ReadFile(filepath.Join(dir, "calls.log"))
+		if err != nil {
+			return []string{}
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
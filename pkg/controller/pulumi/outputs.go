@@ -0,0 +1,79 @@
+package pulumi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"os"
+)
+
+var (
+	errFailedToGetOutputs = errors.New("failed to get stack outputs")
+	errReadOutputsFile    = errors.New("failed to read outputs file")
+)
+
+// pOutputs reads the cluster from the outputs of the stack.
+type pOutputs struct {
+	outputsRunner runner.Runnable
+	naming        terraform.Naming
+}
+
+func (p *pOutputs) GetCluster() (model.Cluster, error) {
+	data, err := p.outputsRunner.Output()
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errFailedToGetOutputs, err)
+	}
+	return readCluster(data, p.naming)
+}
+
+// pOutputsFile reads the cluster from a file with the outputs of the stack instead of running pulumi.
+type pOutputsFile struct {
+	path   string
+	naming terraform.Naming
+}
+
+func (p *pOutputsFile) GetCluster() (model.Cluster, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errReadOutputsFile, err)
+	}
+	return readCluster(data, p.naming)
+}
+
+// readCluster builds the cluster from the output of pulumi stack output --json.
+// The outputs have the same names and shapes as the terraform outputs, so they are read as terraform values.
+func readCluster(data []byte, naming terraform.Naming) (model.Cluster, error) {
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errFailedToGetOutputs, err)
+	}
+	value, err := ctyjson.Unmarshal(data, ty)
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errFailedToGetOutputs, err)
+	}
+	if !ty.IsObjectType() {
+		return model.NewCluster(), fmt.Errorf("%w: %s, expected an object", errFailedToGetOutputs, ty.FriendlyName())
+	}
+	return terraform.ReadOutputs(value.AsValueMap(), naming)
+}
+
+// BuildStateDeterminer builds a pulumi specific ClusterGetter reading the outputs of the selected stack.
+func BuildStateDeterminer(config model.BinaryConfig, naming terraform.Naming) controller.ClusterGetter {
+	return &pOutputs{
+		outputsRunner: buildRunner(config, runner.Options{}.WithSuppressErrOutput(true), "stack", "output", "--json"),
+		naming:        naming,
+	}
+}
+
+// BuildOutputsFileDeterminer builds a ClusterGetter reading a file written by pulumi stack output --json.
+// The binary is never run.
+func BuildOutputsFileDeterminer(path string, naming terraform.Naming) controller.ClusterGetter {
+	return &pOutputsFile{
+		path:   path,
+		naming: naming,
+	}
+}
@@ -0,0 +1,56 @@
+package pulumi
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+)
+
+func TestGetCluster(t *testing.T) {
+	assert := assert.New(t)
+	config, _ := fakePulumi(t)
+	cluster, err := BuildStateDeterminer(config, terraform.Naming{}).GetCluster()
+	require.NoError(t, err)
+	assert.Equal(model.ClusterGroup{
+		Hosts:   []string{"blue-0.example.com", "blue-1.example.com"},
+		Version: semver.MustParse("1.0.0"),
+	}, cluster[model.Blue])
+	assert.Equal(model.ClusterGroup{
+		Hosts:   []string{"green-a.example.com", "green-b.example.com"},
+		Version: semver.MustParse("1.1.0"),
+		Details: []model.Host{
+			{FQDN: "green-a.example.com", IP: "10.0.0.1", Address: "urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-a", Key: "a"},
+			{FQDN: "green-b.example.com", IP: "10.0.0.2", Key: "b"},
+		},
+	}, cluster[model.Green])
+}
+
+func TestReadClusterErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expectedErr error
+	}{
+		{name: "empty", data: `{}`},
+		{name: "null", data: `{"blueHostnames": null, "blueVersion": null}`},
+		{name: "not_json", data: `Current stack is default`, expectedErr: errFailedToGetOutputs},
+		{name: "not_object", data: `["blue-0.example.com"]`, expectedErr: errFailedToGetOutputs},
+		{name: "hostnames_string", data: `{"blueHostnames": "blue-0.example.com"}`, expectedErr: terraform.ErrUnsupportedOutput},
+		{name: "host_without_fqdn", data: `{"blueHostnames": [{"ip": "10.0.0.1"}]}`, expectedErr: terraform.ErrUnsupportedOutput},
+		{name: "version_number", data: `{"blueVersion": 1}`, expectedErr: terraform.ErrUnsupportedOutput},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readCluster([]byte(test.data), terraform.Naming{})
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+)
+
+var (
+	errExportFailure   = errors.New("failed to export stack")
+	errEmptyHostName   = errors.New("hostname can not be empty")
+	errHostNotInGroup  = errors.New("host not found in the cluster")
+	errNoHostResources = errors.New("no resources found for host")
+)
+
+// pGraph finds the urns of the resources of a host.
+type pGraph struct {
+	getter       controller.ClusterGetter
+	exportRunner runner.Runnable
+}
+
+// deployment is the part of pulumi stack export used to find the resources of a host.
+type deployment struct {
+	Deployment struct {
+		Resources []struct {
+			URN     string                 `json:"urn"`
+			Custom  bool                   `json:"custom"`
+			Outputs map[string]interface{} `json:"outputs"`
+		} `json:"resources"`
+	} `json:"deployment"`
+}
+
+// GetResourcesForHost returns the urn of the host if the hostnames output has an address, the urn of its resource.
+// Otherwise the urns of the custom resources with an output equal to the fqdn or ip of the host are returned.
+func (p *pGraph) GetResourcesForHost(hostname string) ([]string, error) {
+	if hostname == "" {
+		return nil, errEmptyHostName
+	}
+	cluster, err := p.getter.GetCluster()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
+	}
+	var (
+		host  model.Host
+		found bool
+	)
+	for _, group := range cluster {
+		if contains(group.Hosts, hostname) {
+			host = group.Host(hostname)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", errHostNotInGroup, hostname)
+	}
+	if host.Address != "" {
+		return []string{host.Address}, nil
+	}
+
+	data, err := p.exportRunner.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errExportFailure, err)
+	}
+	var export deployment
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", errExportFailure, err)
+	}
+	urns := make([]string, 0)
+	for _, resource := range export.Deployment.Resources {
+		// component resources and the stack itself repeat the outputs of their children.
+		if !resource.Custom {
+			continue
+		}
+		for _, output := range resource.Outputs {
+			if value, ok := output.(string); ok && value != "" && (value == host.FQDN || value == host.IP) {
+				urns = append(urns, resource.URN)
+				break
+			}
+		}
+	}
+	if len(urns) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNoHostResources, hostname)
+	}
+	return urns, nil
+}
+
+// replaceFlag passes an urn as --replace to pulumi up.
+func replaceFlag(urn string) []string {
+	return []string{"--replace", urn}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package pulumi
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strings"
+	"testing"
+)
+
+func TestGetResourcesForHost(t *testing.T) {
+	tests := []struct {
+		name         string
+		hostname     string
+		expectedURNs []string
+		expectedErr  error
+	}{
+		{
+			name:         "address",
+			hostname:     "green-a.example.com",
+			expectedURNs: []string{"urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-a"},
+		},
+		{
+			name:     "fqdn_output",
+			hostname: "blue-1.example.com",
+			expectedURNs: []string{
+				"urn:pulumi:default::cluster::aws:ec2/instance:Instance::blue-1",
+				"urn:pulumi:default::cluster::aws:route53/record:Record::blue-1",
+			},
+		},
+		{
+			name:         "ip_output",
+			hostname:     "green-b.example.com",
+			expectedURNs: []string{"urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-b"},
+		},
+		{name: "no_resources", hostname: "blue-0.example.com", expectedErr: errNoHostResources},
+		{name: "not_in_cluster", hostname: "red-0.example.com", expectedErr: errHostNotInGroup},
+		{name: "empty", expectedErr: errEmptyHostName},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			config, _ := fakePulumi(t)
+			urns, err := BuildClusterGraphRunner(BuildStateDeterminer(config, terraform.Naming{}), config).GetResourcesForHost(test.hostname)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedURNs, urns)
+		})
+	}
+}
+
+func TestReplaceHost(t *testing.T) {
+	assert := assert.New(t)
+	config, calls := fakePulumi(t)
+	pulumi := BuildController(config, PulumiTransitionConfig{Args: []model.ValuePair{{Key: "region", Value: "us-east-1"}}}, terraform.Naming{})
+	target := model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("1.0.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("1.1.0")},
+	}
+	apply := pulumi.CreateApply(target, model.Step{model.Blue: 0, model.Green: 2})
+
+	assert.NoError(pulumi.TaintHost("green-a.example.com"))
+	// the failed up keeps the replacement for the next try.
+	t.Setenv("FAKE_PULUMI_FAIL", "true")
+	_, err := apply.Output()
+	assert.Error(err)
+	t.Setenv("FAKE_PULUMI_FAIL", "")
+	_, err = apply.Output()
+	assert.NoError(err)
+	_, err = apply.Output()
+	assert.NoError(err)
+
+	up := "up --yes --refresh --config versionBlueCount=0 --config versionBlue=1.0.0 --config versionGreenCount=2 " +
+		"--config versionGreen=1.1.0 --config region=us-east-1 --non-interactive"
+	replaceUp := "up --yes --replace urn:pulumi:default::cluster::aws:ec2/instance:Instance::green-a " + strings.TrimPrefix(up, "up --yes ")
+	assert.Equal([]string{
+		"stack output --json --non-interactive",
+		replaceUp,
+		replaceUp,
+		up,
+	}, calls())
+}
@@ -0,0 +1,187 @@
+package pulumi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+)
+
+var (
+	errListStackFailure   = errors.New("failed to list stacks")
+	errSelectStackFailure = errors.New("failed to select stack")
+	errCreateStackFailure = errors.New("failed to create stack")
+	errDeleteStackFailure = errors.New("failed to delete stack")
+	errStackExists        = errors.New("stack already exists")
+	errEmptyStack         = errors.New("stack can not be empty")
+)
+
+// defaultBinary is used if the BinaryConfig has no Binary.
+const defaultBinary = "pulumi"
+
+// pStack is a pulumi specific WorkspaceManager, each workspace is a stack of the project.
+type pStack struct {
+	listRunner        runner.Runnable
+	selectStackRunner func(stack string) runner.Runnable
+	newStackRunner    func(stack string) runner.Runnable
+	deleteStackRunner func(stack string, force bool) runner.Runnable
+	// create creates a missing stack when it is selected, otherwise selecting it fails.
+	create bool
+}
+
+// stackSummary is a stack of pulumi stack ls --json.
+type stackSummary struct {
+	Name    string `json:"name"`
+	Current bool   `json:"current"`
+}
+
+func (p *pStack) SelectWorkspace(workspace string) error {
+	if workspace == "" {
+		// if workspace is empty use what is currently selected
+		return nil
+	}
+	stacks, err := p.stacks()
+	if err != nil {
+		return err
+	}
+	for _, stack := range stacks {
+		if stack.Name != workspace {
+			continue
+		}
+		if stack.Current {
+			return nil
+		}
+		if _, err := p.selectStackRunner(workspace).Output(); err != nil {
+			return fmt.Errorf("%v: %w for %s", errSelectStackFailure, err, workspace)
+		}
+		return nil
+	}
+
+	if !p.create {
+		return fmt.Errorf("%w: %s", controller.ErrWorkspaceNotFound, workspace)
+	}
+	// stack init selects the new stack.
+	if _, err := p.newStackRunner(workspace).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errCreateStackFailure, err, workspace)
+	}
+	return nil
+}
+
+func (p *pStack) ListWorkspaces() ([]string, error) {
+	stacks, err := p.stacks()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(stacks))
+	for _, stack := range stacks {
+		names = append(names, stack.Name)
+	}
+	return names, nil
+}
+
+func (p *pStack) CurrentWorkspace() (string, error) {
+	stacks, err := p.stacks()
+	if err != nil {
+		return "", err
+	}
+	for _, stack := range stacks {
+		if stack.Current {
+			return stack.Name, nil
+		}
+	}
+	// a project without a selected stack.
+	return "", nil
+}
+
+func (p *pStack) NewWorkspace(workspace string) error {
+	if workspace == "" {
+		return errEmptyStack
+	}
+	exists, err := p.exists(workspace)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", errStackExists, workspace)
+	}
+	if _, err := p.newStackRunner(workspace).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errCreateStackFailure, err, workspace)
+	}
+	return nil
+}
+
+func (p *pStack) DeleteWorkspace(workspace string, force bool) error {
+	if workspace == "" {
+		return errEmptyStack
+	}
+	exists, err := p.exists(workspace)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", controller.ErrWorkspaceNotFound, workspace)
+	}
+	if _, err := p.deleteStackRunner(workspace, force).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errDeleteStackFailure, err, workspace)
+	}
+	return nil
+}
+
+// exists returns true if the stack is listed, names must match exactly so prod doesn't match prod-east.
+func (p *pStack) exists(workspace string) (bool, error) {
+	names, err := p.ListWorkspaces()
+	if err != nil {
+		return false, err
+	}
+	for _, name := range names {
+		if name == workspace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *pStack) stacks() ([]stackSummary, error) {
+	data, err := p.listRunner.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errListStackFailure, err)
+	}
+	stacks := make([]stackSummary, 0)
+	if err := json.Unmarshal(data, &stacks); err != nil {
+		return nil, fmt.Errorf("%w: %v", errListStackFailure, err)
+	}
+	return stacks, nil
+}
+
+// BuildStackManager builds a pulumi specific WorkspaceManager, a missing stack is created with pulumi stack init.
+// If create is false, selecting a missing stack fails instead of creating it.
+func BuildStackManager(config model.BinaryConfig, create bool) controller.WorkspaceManager {
+	return &pStack{
+		listRunner: buildRunner(config, runner.Options{}.WithSuppressErrOutput(true), "stack", "ls", "--json"),
+		selectStackRunner: func(stack string) runner.Runnable {
+			return buildRunner(config, runner.Options{}, "stack", "select", stack)
+		},
+		newStackRunner: func(stack string) runner.Runnable {
+			return buildRunner(config, runner.Options{}, "stack", "init", stack)
+		},
+		deleteStackRunner: func(stack string, force bool) runner.Runnable {
+			if force {
+				return buildRunner(config, runner.Options{}, "stack", "rm", "--yes", "--force", stack)
+			}
+			return buildRunner(config, runner.Options{}, "stack", "rm", "--yes", stack)
+		},
+		create: create,
+	}
+}
+
+// buildRunner builds a non-interactive pulumi command with the Environment of the config.
+func buildRunner(config model.BinaryConfig, options runner.Options, cmdArgs ...string) runner.Runnable {
+	binary := config.Binary
+	if binary == "" {
+		binary = defaultBinary
+	}
+	r := runner.NewCMDRunner(config.WorkingDirectory, binary, options, append(cmdArgs, "--non-interactive")...)
+	return runner.AddEnvironment(r, "", config.Environment)
+}
@@ -0,0 +1,88 @@
+package pulumi
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"testing"
+)
+
+func TestStackManager(t *testing.T) {
+	tests := []struct {
+		name          string
+		operation     func(manager controller.WorkspaceManager) error
+		create        bool
+		expectedCalls []string
+		expectedErr   error
+	}{
+		{
+			name:          "select_empty",
+			operation:     func(m controller.WorkspaceManager) error { return m.SelectWorkspace("") },
+			expectedCalls: []string{},
+		},
+		{
+			name:          "select_current",
+			operation:     func(m controller.WorkspaceManager) error { return m.SelectWorkspace("default") },
+			expectedCalls: []string{"stack ls --json --non-interactive"},
+		},
+		{
+			name:          "select_existing",
+			operation:     func(m controller.WorkspaceManager) error { return m.SelectWorkspace("prod-east") },
+			expectedCalls: []string{"stack ls --json --non-interactive", "stack select prod-east --non-interactive"},
+		},
+		{
+			name:          "select_prefix_creates",
+			operation:     func(m controller.WorkspaceManager) error { return m.SelectWorkspace("prod") },
+			create:        true,
+			expectedCalls: []string{"stack ls --json --non-interactive", "stack init prod --non-interactive"},
+		},
+		{
+			name:          "select_missing_without_create",
+			operation:     func(m controller.WorkspaceManager) error { return m.SelectWorkspace("prod") },
+			expectedCalls: []string{"stack ls --json --non-interactive"},
+			expectedErr:   controller.ErrWorkspaceNotFound,
+		},
+		{
+			name:          "new_existing",
+			operation:     func(m controller.WorkspaceManager) error { return m.NewWorkspace("prod-east") },
+			expectedCalls: []string{"stack ls --json --non-interactive"},
+			expectedErr:   errStackExists,
+		},
+		{
+			name:          "delete_force",
+			operation:     func(m controller.WorkspaceManager) error { return m.DeleteWorkspace("prod-east", true) },
+			expectedCalls: []string{"stack ls --json --non-interactive", "stack rm --yes --force prod-east --non-interactive"},
+		},
+		{
+			name:          "delete_missing",
+			operation:     func(m controller.WorkspaceManager) error { return m.DeleteWorkspace("prod", false) },
+			expectedCalls: []string{"stack ls --json --non-interactive"},
+			expectedErr:   controller.ErrWorkspaceNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			config, calls := fakePulumi(t)
+			err := test.operation(BuildStackManager(config, test.create))
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedCalls, calls())
+		})
+	}
+}
+
+func TestCurrentWorkspace(t *testing.T) {
+	assert := assert.New(t)
+	config, _ := fakePulumi(t)
+	manager := BuildStackManager(config, false)
+	current, err := manager.CurrentWorkspace()
+	assert.NoError(err)
+	assert.Equal("default", current)
+	workspaces, err := manager.ListWorkspaces()
+	assert.NoError(err)
+	assert.Equal([]string{"default", "prod-east"}, workspaces)
+}
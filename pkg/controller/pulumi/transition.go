@@ -0,0 +1,112 @@
+package pulumi
+
+import (
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strconv"
+)
+
+// pTransition is a pulumi specific implementation of Transition, each step is a pulumi up with the counts and
+// versions of the step as stack config.
+type pTransition struct {
+	config           model.BinaryConfig
+	transitionConfig PulumiTransitionConfig
+	naming           terraform.Naming
+	// pending are the urns to replace with the next up.
+	pending *controller.PendingReplacements
+	// ups is the number of ups created, the stack is refreshed on the first one with FirstRefresh.
+	ups int
+}
+
+func (p *pTransition) CreateApply(target model.ClusterState, step model.Step) runner.Runnable {
+	tuningArgs := p.tuningArgs(p.ups == 0)
+	p.ups++
+	return controller.NewReplaceApply(p.pending, replaceFlag, func(replaceArgs []string) runner.Runnable {
+		cmdArgs := append([]string{"up", "--yes"}, replaceArgs...)
+		cmdArgs = append(cmdArgs, tuningArgs...)
+		return buildRunner(p.config, runner.Options{
+			ShowOutput:        p.transitionConfig.AttachStdOut,
+			SuppressErrOutput: !p.transitionConfig.AttachStdErr,
+		}, append(cmdArgs, p.stepArgs(target, step)...)...)
+	})
+}
+
+// tuningArgs returns the arguments of the Parallelism and Refresh, pulumi up doesn't refresh by default.
+func (p *pTransition) tuningArgs(first bool) []string {
+	args := make([]string, 0)
+	if p.transitionConfig.Parallelism > 0 {
+		args = append(args, "--parallel="+strconv.Itoa(p.transitionConfig.Parallelism))
+	}
+	switch p.transitionConfig.Refresh {
+	case "", terraform.AlwaysRefresh:
+		args = append(args, "--refresh")
+	case terraform.FirstRefresh:
+		if first {
+			args = append(args, "--refresh")
+		}
+	}
+	return args
+}
+
+// stepArgs returns the stack config of the step, pulumi up saves it to the stack config.
+func (p *pTransition) stepArgs(target model.ClusterState, step model.Step) []string {
+	cmdArgs := make([]string, 0)
+	for _, color := range model.ValidColors {
+		cmdArgs = append(cmdArgs,
+			"--config", fmt.Sprintf("%s=%d", p.naming.CountVariable(color), step[color]),
+		)
+		cmdArgs = append(cmdArgs,
+			"--config", fmt.Sprintf("%s=%s", p.naming.VersionVariable(color), target[color].Version.String()),
+		)
+	}
+
+	for _, elem := range p.transitionConfig.Args {
+		cmdArgs = append(cmdArgs, "--config", fmt.Sprintf("%s=%s", elem.Key, elem.Value))
+	}
+	return cmdArgs
+}
+
+type PulumiTransitionConfig struct {
+	Args         []model.ValuePair
+	AttachStdOut bool
+	AttachStdErr bool
+	// Parallelism limits the concurrent operations of pulumi up with --parallel.
+	// 0 uses the pulumi default.
+	Parallelism int
+	// Refresh is when the stack is refreshed, the zero value is AlwaysRefresh like terraform.
+	Refresh terraform.Refresh
+}
+
+// BuildController builds a pulumi specific controller.Controller. The workspace is the stack, the cluster is read from
+// the stack outputs and the hosts failing validation are replaced by the next pulumi up with --replace.
+func BuildController(config model.BinaryConfig, transitionConfig PulumiTransitionConfig, naming terraform.Naming) controller.Controller {
+	clusterGetter := BuildStateDeterminer(config, naming)
+	pending := &controller.PendingReplacements{}
+	return struct {
+		controller.WorkspaceSelecter
+		controller.ClusterGetter
+		controller.Tainter
+		controller.ApplyBuilder
+	}{
+		WorkspaceSelecter: BuildStackManager(config, true),
+		ClusterGetter:     clusterGetter,
+		Tainter:           controller.NewReplaceTainter(BuildClusterGraphRunner(clusterGetter, config), pending),
+		ApplyBuilder: &pTransition{
+			config:           config,
+			transitionConfig: transitionConfig,
+			naming:           naming,
+			pending:          pending,
+		},
+	}
+}
+
+// BuildClusterGraphRunner builds a pulumi specific ClusterGraph, returning the urns of the resources of a host.
+func BuildClusterGraphRunner(getter controller.ClusterGetter, config model.BinaryConfig) controller.ClusterGraph {
+	return &pGraph{
+		getter:       getter,
+		exportRunner: buildRunner(config, runner.Options{}.WithSuppressErrOutput(true), "stack", "export"),
+	}
+}
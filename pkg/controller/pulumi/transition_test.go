@@ -0,0 +1,36 @@
+package pulumi
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller/terraform"
+	"testing"
+)
+
+func TestTuningArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       PulumiTransitionConfig
+		expectedArgs [][]string
+	}{
+		{
+			name:         "default",
+			expectedArgs: [][]string{{"--refresh"}, {"--refresh"}},
+		},
+		{
+			name:         "refresh_first",
+			config:       PulumiTransitionConfig{Refresh: terraform.FirstRefresh, Parallelism: 4},
+			expectedArgs: [][]string{{"--parallel=4", "--refresh"}, {"--parallel=4"}},
+		},
+		{
+			name:         "refresh_never",
+			config:       PulumiTransitionConfig{Refresh: terraform.NeverRefresh},
+			expectedArgs: [][]string{{}, {}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transition := &pTransition{transitionConfig: test.config}
+			assert.Equal(t, test.expectedArgs, [][]string{transition.tuningArgs(true), transition.tuningArgs(false)})
+		})
+	}
+}
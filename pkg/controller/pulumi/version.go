@@ -0,0 +1,25 @@
+package pulumi
+
+import (
+	"errors"
+	"fmt"
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
+)
+
+var errVersionFailure = errors.New("failed to get pulumi version")
+
+// DetectVersion runs pulumi version, which prints the version like v3.100.0.
+func DetectVersion(config model.BinaryConfig) (semver.Version, error) {
+	data, err := buildRunner(config, runner.Options{}.WithSuppressErrOutput(true), "version").Output()
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("%w: %v", errVersionFailure, err)
+	}
+	version, err := semver.ParseTolerant(strings.TrimSpace(string(data)))
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("%w: %v", errVersionFailure, err)
+	}
+	return version, nil
+}
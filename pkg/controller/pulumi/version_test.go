@@ -0,0 +1,14 @@
+package pulumi
+
+import (
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectVersion(t *testing.T) {
+	config, _ := fakePulumi(t)
+	version, err := DetectVersion(config)
+	assert.NoError(t, err)
+	assert.Equal(t, semver.MustParse("3.100.0"), version)
+}
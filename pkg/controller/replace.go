@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"sync"
+)
+
+// ErrReplaceHostFailure is returned if the resources of a host to replace can't be found.
+var ErrReplaceHostFailure = errors.New("failed to replace host")
+
+// ReplaceError is returned by an apply replacing resources that failed, the Resources are still to be replaced.
+// They are only kept in memory, so a transition resumed later must replace them again.
+type ReplaceError struct {
+	Resources []string
+	Err       error
+}
+
+func (e ReplaceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ReplaceError) Unwrap() error {
+	return e.Err
+}
+
+// PendingReplacements are the resources to replace with the next apply, for tools that replace resources with an
+// argument of the apply instead of tainting them. Hosts are checked concurrently, so the resources are guarded by a
+// mutex.
+type PendingReplacements struct {
+	lock      sync.Mutex
+	resources []string
+}
+
+// Add adds the resources that are not pending yet.
+func (p *PendingReplacements) Add(resources []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, resource := range resources {
+		if !contains(p.resources, resource) {
+			p.resources = append(p.resources, resource)
+		}
+	}
+}
+
+// List returns a copy of the pending resources.
+func (p *PendingReplacements) List() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]string{}, p.resources...)
+}
+
+// Remove drops the resources that have been replaced.
+func (p *PendingReplacements) Remove(resources []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	remaining := make([]string, 0, len(p.resources))
+	for _, pending := range p.resources {
+		if !contains(resources, pending) {
+			remaining = append(remaining, pending)
+		}
+	}
+	p.resources = remaining
+}
+
+// ReplaceFlag returns the arguments of the apply that replace a resource, e.g. -replace=<resource>.
+type ReplaceFlag func(resource string) []string
+
+// NewReplaceTainter builds a Tainter that adds the resources of a host to the PendingReplacements,
+// instead of tainting them.
+func NewReplaceTainter(graph ClusterGraph, pending *PendingReplacements) Tainter {
+	return &replaceTainter{graph: graph, pending: pending}
+}
+
+type replaceTainter struct {
+	graph   ClusterGraph
+	pending *PendingReplacements
+}
+
+func (r *replaceTainter) TaintResources(resources []string) error {
+	r.pending.Add(resources)
+	return nil
+}
+
+func (r *replaceTainter) TaintHost(hostname string) error {
+	resources, err := r.graph.GetResourcesForHost(hostname)
+	if err != nil {
+		return fmt.Errorf("%w: %v for host %s", ErrReplaceHostFailure, err, hostname)
+	}
+	return r.TaintResources(resources)
+}
+
+// NewReplaceApply builds an apply that passes the PendingReplacements with the flag each time it runs, so running it
+// again after a host failed replaces the resources of the host. The resources are removed once an apply succeeds.
+// build builds the apply with the replace arguments.
+func NewReplaceApply(pending *PendingReplacements, flag ReplaceFlag, build func(replaceArgs []string) runner.Runnable) runner.Runnable {
+	return &replaceApply{pending: pending, flag: flag, build: build}
+}
+
+type replaceApply struct {
+	pending *PendingReplacements
+	flag    ReplaceFlag
+	build   func(replaceArgs []string) runner.Runnable
+}
+
+func (r *replaceApply) Output() ([]byte, error) {
+	resources := r.pending.List()
+	data, err := r.build(r.replaceArgs(resources)).Output()
+	if err != nil {
+		if len(resources) > 0 {
+			return data, ReplaceError{Resources: resources, Err: err}
+		}
+		return data, err
+	}
+	r.pending.Remove(resources)
+	return data, nil
+}
+
+func (r *replaceApply) String() string {
+	return r.build(r.replaceArgs(r.pending.List())).String()
+}
+
+func (r *replaceApply) replaceArgs(resources []string) []string {
+	args := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		args = append(args, r.flag(resource)...)
+	}
+	return args
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
+	"testing"
+)
+
+// mapGraph returns the resources of the hosts in the map.
+type mapGraph map[string][]string
+
+func (m mapGraph) GetResourcesForHost(hostname string) ([]string, error) {
+	resources, ok := m[hostname]
+	if !ok {
+		return nil, fmt.Errorf("no resources for %s", hostname)
+	}
+	return resources, nil
+}
+
+// argsRunnable records the arguments it was built with.
+type argsRunnable struct {
+	args []string
+	err  error
+}
+
+func (a argsRunnable) Output() ([]byte, error) {
+	return []byte(a.String()), a.err
+}
+
+func (a argsRunnable) String() string {
+	return strings.Join(a.args, " ")
+}
+
+func TestReplaceHost(t *testing.T) {
+	assert := assert.New(t)
+	graph := mapGraph{
+		"a.example.com": {"module.blue.random_id.ID[0]", "module.blue.data.null_data_source.name[0]"},
+		"b.example.com": {"module.blue.random_id.ID[1]"},
+	}
+
+	pending := &PendingReplacements{}
+	tainter := NewReplaceTainter(graph, pending)
+	applyErr := errors.New("apply failed")
+	var failApply bool
+	flag := func(resource string) []string { return []string{"-replace=" + resource} }
+	apply := NewReplaceApply(pending, flag, func(replaceArgs []string) runner.Runnable {
+		r := argsRunnable{args: append([]string{"apply"}, replaceArgs...)}
+		if failApply {
+			r.err = applyErr
+		}
+		return r
+	})
+
+	data, err := apply.Output()
+	assert.NoError(err)
+	assert.Equal("apply", string(data))
+
+	assert.NoError(tainter.TaintHost("a.example.com"))
+	assert.NoError(tainter.TaintHost("b.example.com"))
+	assert.ErrorIs(tainter.TaintHost("c.example.com"), ErrReplaceHostFailure)
+	// the same resource is only replaced once.
+	assert.NoError(tainter.TaintResources([]string{"module.blue.random_id.ID[1]"}))
+	expected := "apply -replace=module.blue.random_id.ID[0] -replace=module.blue.data.null_data_source.name[0] -replace=module.blue.random_id.ID[1]"
+	assert.Equal(expected, apply.String())
+
+	// a failed apply keeps the resources for the next apply.
+	failApply = true
+	_, err = apply.Output()
+	assert.ErrorIs(err, applyErr)
+	var replaceErr ReplaceError
+	if assert.ErrorAs(err, &replaceErr) {
+		assert.Equal([]string{"module.blue.random_id.ID[0]", "module.blue.data.null_data_source.name[0]", "module.blue.random_id.ID[1]"}, replaceErr.Resources)
+	}
+	failApply = false
+	data, err = apply.Output()
+	assert.NoError(err)
+	assert.Equal(expected, string(data))
+
+	// replaced resources are not replaced again.
+	assert.Equal("apply", apply.String())
+}
+
+func TestReplaceApplyFlag(t *testing.T) {
+	assert := assert.New(t)
+	pending := &PendingReplacements{}
+	pending.Add([]string{"urn:a", "urn:b"})
+	flag := func(urn string) []string { return []string{"--replace", urn} }
+	apply := NewReplaceApply(pending, flag, func(replaceArgs []string) runner.Runnable {
+		return argsRunnable{args: append([]string{"up"}, replaceArgs...)}
+	})
+	assert.Equal("up --replace urn:a --replace urn:b", apply.String())
+}
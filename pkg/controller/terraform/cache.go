@@ -0,0 +1,81 @@
+package terraform
+
+import (
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"sync"
+)
+
+// cachedCluster caches the cluster of a ClusterGetter until it is invalidated, so tainting many hosts
+// of a step pulls the state once. Errors are not cached.
+type cachedCluster struct {
+	getter controller.ClusterGetter
+
+	lock    sync.Mutex
+	cluster model.Cluster
+	cached  bool
+}
+
+func (c *cachedCluster) GetCluster() (model.Cluster, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.cached {
+		return c.cluster, nil
+	}
+	cluster, err := c.getter.GetCluster()
+	if err != nil {
+		return cluster, err
+	}
+	c.cluster = cluster
+	c.cached = true
+	return cluster, nil
+}
+
+func (c *cachedCluster) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cached = false
+	c.cluster = nil
+}
+
+// cachedRunnable caches the output of a Runnable, e.g. terraform state list, until it is invalidated.
+// Errors are not cached.
+type cachedRunnable struct {
+	runner.Runnable
+
+	lock   sync.Mutex
+	data   []byte
+	cached bool
+}
+
+func (c *cachedRunnable) Output() ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.cached {
+		return c.data, nil
+	}
+	data, err := c.Runnable.Output()
+	if err != nil {
+		return data, err
+	}
+	c.data = data
+	c.cached = true
+	return data, nil
+}
+
+func (c *cachedRunnable) Invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cached = false
+	c.data = nil
+}
+
+// caches invalidates every Cache at once.
+type caches []controller.Cache
+
+func (c caches) Invalidate() {
+	for _, cache := range c {
+		cache.Invalidate()
+	}
+}
@@ -0,0 +1,97 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+)
+
+type countingGetter struct {
+	calls int
+	err   error
+}
+
+func (c *countingGetter) GetCluster() (model.Cluster, error) {
+	c.calls++
+	return model.NewCluster(), c.err
+}
+
+type countingRunnable struct {
+	calls int
+	err   error
+}
+
+func (c *countingRunnable) Output() ([]byte, error) {
+	c.calls++
+	return []byte(goodList), c.err
+}
+
+func (c *countingRunnable) String() string {
+	return "counting runnable"
+}
+
+func TestCachedCluster(t *testing.T) {
+	assert := assert.New(t)
+	getter := &countingGetter{}
+	cache := &cachedCluster{getter: getter}
+
+	for i := 0; i < 3; i++ {
+		cluster, err := cache.GetCluster()
+		assert.NoError(err)
+		assert.Equal(model.NewCluster(), cluster)
+	}
+	assert.Equal(1, getter.calls)
+
+	cache.Invalidate()
+	_, err := cache.GetCluster()
+	assert.NoError(err)
+	assert.Equal(2, getter.calls)
+
+	// errors are not cached
+	cache.Invalidate()
+	getter.err = errors.New("state pull failed")
+	_, err = cache.GetCluster()
+	assert.Error(err)
+	getter.err = nil
+	_, err = cache.GetCluster()
+	assert.NoError(err)
+	assert.Equal(4, getter.calls)
+}
+
+func TestCachedRunnable(t *testing.T) {
+	assert := assert.New(t)
+	listRunner := &countingRunnable{}
+	cache := &cachedRunnable{Runnable: listRunner}
+
+	for i := 0; i < 3; i++ {
+		data, err := cache.Output()
+		assert.NoError(err)
+		assert.Equal(goodList, string(data))
+	}
+	assert.Equal(1, listRunner.calls)
+	assert.Equal("counting runnable", cache.String())
+
+	// errors are not cached
+	listRunner.err = errors.New("state list failed")
+	caches{cache}.Invalidate()
+	_, err := cache.Output()
+	assert.Error(err)
+	_, err = cache.Output()
+	assert.Error(err)
+	assert.Equal(3, listRunner.calls)
+}
+
+func TestCachedClusterGraph(t *testing.T) {
+	assert := assert.New(t)
+	listRunner := &countingRunnable{}
+	getter := &cachedCluster{getter: &tState{stateRunner: simplerunnable{Name: "state", Data: []byte(cleanState)}}}
+	graph := &tGraph{getter: getter, listRunner: &cachedRunnable{Runnable: listRunner}}
+
+	for _, host := range []string{"carousel-demo-ffdbb6.example.com", "carousel-demo-ea9412.example.com"} {
+		resources, err := graph.GetResourcesForHost(host)
+		assert.NoError(err)
+		assert.NotEmpty(resources)
+	}
+	assert.Equal(1, listRunner.calls)
+}
@@ -0,0 +1,206 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"path/filepath"
+	"sort"
+)
+
+var (
+	errNoConfigFiles = errors.New("no terraform files found")
+	errParseProject  = errors.New("failed to parse terraform files")
+)
+
+// Problem is something in the terraform project that breaks the contract with carousel.
+type Problem struct {
+	// Color is the Color Group the problem is about.
+	Color model.Color `json:"group"`
+	// Location is the file and line of the problem, empty if something is missing.
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Location == "" {
+		return fmt.Sprintf("%s: %s", p.Color, p.Message)
+	}
+	return fmt.Sprintf("%s: %s (%s)", p.Color, p.Message, p.Location)
+}
+
+// project is the root module of a terraform project, as far as carousel cares about it.
+type project struct {
+	variables map[string]hcl.Range
+	outputs   map[string]*hclsyntax.Attribute
+	modules   map[string]*hclsyntax.Block
+	locals    map[string]*hclsyntax.Attribute
+	// used are the variables referenced anywhere in the project.
+	used map[string]bool
+}
+
+// CheckProject parses the .tf files of dir and checks that each Color Group has its count and version
+// variables and its hostnames and version outputs. A module referenced by the outputs of a Color Group must
+// be given the count and version variables of the Color Group, directly or through locals.
+func CheckProject(dir string, naming Naming) ([]Problem, error) {
+	p, err := parseProject(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]Problem, 0)
+	for _, color := range model.ValidColors {
+		variables := []string{naming.CountVariable(color), naming.VersionVariable(color)}
+		for _, name := range variables {
+			location, ok := p.variables[name]
+			switch {
+			case !ok:
+				problems = append(problems, Problem{Color: color, Message: fmt.Sprintf("missing variable %s", name)})
+			case !p.used[name]:
+				problems = append(problems, Problem{Color: color, Location: describeRange(location), Message: fmt.Sprintf("variable %s is never used", name)})
+			}
+		}
+
+		for _, name := range []string{naming.HostnamesOutput(color), naming.VersionOutput(color)} {
+			output, ok := p.outputs[name]
+			if !ok {
+				problems = append(problems, Problem{Color: color, Message: fmt.Sprintf("missing output %s", name)})
+				continue
+			}
+			for _, moduleName := range p.modulesOf(output.Expr) {
+				module, ok := p.modules[moduleName]
+				if !ok {
+					problems = append(problems, Problem{Color: color, Location: describeRange(output.SrcRange), Message: fmt.Sprintf("output %s references missing module %s", name, moduleName)})
+					continue
+				}
+				wired := p.variablesOf(module.Body)
+				for _, variable := range variables {
+					if !wired[variable] {
+						problems = append(problems, Problem{Color: color, Location: describeRange(module.DefRange()), Message: fmt.Sprintf("module %s of output %s isn't given variable %s", moduleName, name, variable)})
+					}
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+func parseProject(dir string) (project, error) {
+	p := project{
+		variables: map[string]hcl.Range{},
+		outputs:   map[string]*hclsyntax.Attribute{},
+		modules:   map[string]*hclsyntax.Block{},
+		locals:    map[string]*hclsyntax.Attribute{},
+		used:      map[string]bool{},
+	}
+	if dir == "" {
+		dir = "."
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return p, fmt.Errorf("%w: %v", errParseProject, err)
+	}
+	if len(files) == 0 {
+		return p, fmt.Errorf("%w: in %s", errNoConfigFiles, dir)
+	}
+	sort.Strings(files)
+
+	parser := hclparse.NewParser()
+	for _, filename := range files {
+		file, diags := parser.ParseHCLFile(filename)
+		if diags.HasErrors() {
+			return p, fmt.Errorf("%w: %v", errParseProject, diags)
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			return p, fmt.Errorf("%w: %s is not native syntax", errParseProject, filename)
+		}
+		for _, block := range body.Blocks {
+			switch {
+			case block.Type == "variable" && len(block.Labels) == 1:
+				p.variables[block.Labels[0]] = block.DefRange()
+			case block.Type == "output" && len(block.Labels) == 1:
+				if value, ok := block.Body.Attributes["value"]; ok {
+					p.outputs[block.Labels[0]] = value
+				}
+			case block.Type == "module" && len(block.Labels) == 1:
+				p.modules[block.Labels[0]] = block
+			case block.Type == "locals":
+				for name, attribute := range block.Body.Attributes {
+					p.locals[name] = attribute
+				}
+			}
+		}
+		for _, block := range body.Blocks {
+			// a validation of a variable doesn't use it.
+			if block.Type == "variable" {
+				continue
+			}
+			for name := range p.variablesOf(block.Body) {
+				p.used[name] = true
+			}
+		}
+	}
+	return p, nil
+}
+
+// variablesOf returns the variables referenced by every expression of the body, directly or through locals.
+func (p project) variablesOf(body *hclsyntax.Body) map[string]bool {
+	variables := map[string]bool{}
+	p.walk(body, func(expr hclsyntax.Expression) {
+		p.collect(expr, "var", variables, map[string]bool{})
+	})
+	return variables
+}
+
+// modulesOf returns the modules referenced by the expression, directly or through locals.
+func (p project) modulesOf(expr hclsyntax.Expression) []string {
+	modules := map[string]bool{}
+	p.collect(expr, "module", modules, map[string]bool{})
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collect adds the names of the references of expr with the root, e.g. var.versionBlue, following locals.
+// seen guards against locals referencing each other.
+func (p project) collect(expr hclsyntax.Expression, root string, names map[string]bool, seen map[string]bool) {
+	for _, traversal := range expr.Variables() {
+		if len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		switch traversal.RootName() {
+		case root:
+			names[attr.Name] = true
+		case "local":
+			if local, ok := p.locals[attr.Name]; ok && !seen[attr.Name] {
+				seen[attr.Name] = true
+				p.collect(local.Expr, root, names, seen)
+			}
+		}
+	}
+}
+
+// walk calls f with every expression of the body and its nested blocks.
+func (p project) walk(body *hclsyntax.Body, f func(expr hclsyntax.Expression)) {
+	for _, attribute := range body.Attributes {
+		f(attribute.Expr)
+	}
+	for _, block := range body.Blocks {
+		p.walk(block.Body, f)
+	}
+}
+
+func describeRange(r hcl.Range) string {
+	return fmt.Sprintf("%s:%d", r.Filename, r.Start.Line)
+}
@@ -0,0 +1,171 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const contractVariables = `
+variable "versionBlueCount" {}
+variable "versionBlue" {}
+variable "versionGreenCount" {}
+variable "versionGreen" {}
+`
+
+const contractOutputs = `
+output "blueHostnames" {
+  value = module.blue.fqdn
+}
+output "greenHostnames" {
+  value = module.green.fqdn
+}
+output "blueVersion" {
+  value = var.versionBlue
+}
+output "greenVersion" {
+  value = var.versionGreen
+}
+`
+
+const contractModules = `
+module "blue" {
+  source  = "./modules/info"
+  servers = var.versionBlueCount
+  version = var.versionBlue
+}
+module "green" {
+  source  = "./modules/info"
+  servers = local.green.count
+  version = local.green.version
+}
+locals {
+  green = {
+    count   = var.versionGreenCount
+    version = var.versionGreen
+  }
+}
+`
+
+func TestCheckProject(t *testing.T) {
+	tests := []struct {
+		name             string
+		files            map[string]string
+		expectedProblems []Problem
+		expectedErr      error
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"variables.tf": contractVariables,
+				"outputs.tf":   contractOutputs,
+				"main.tf":      contractModules,
+			},
+			expectedProblems: []Problem{},
+		},
+		{
+			name: "missing_variables_and_outputs",
+			files: map[string]string{
+				"main.tf": `
+variable "versionBlueCount" {}
+variable "versionBlue" {}
+output "blueHostnames" {
+  value = [for i in range(var.versionBlueCount) : "${var.versionBlue}-${i}"]
+}
+output "blueVersion" {
+  value = var.versionBlue
+}
+`,
+			},
+			expectedProblems: []Problem{
+				{Color: model.Green, Message: "missing variable versionGreenCount"},
+				{Color: model.Green, Message: "missing variable versionGreen"},
+				{Color: model.Green, Message: "missing output greenHostnames"},
+				{Color: model.Green, Message: "missing output greenVersion"},
+			},
+		},
+		{
+			name: "module_not_wired",
+			files: map[string]string{
+				"variables.tf": contractVariables,
+				"outputs.tf":   contractOutputs,
+				"main.tf": `
+module "blue" {
+  source  = "./modules/info"
+  servers = 2
+  version = var.versionBlue
+}
+module "green" {
+  source  = "./modules/info"
+  servers = var.versionGreenCount
+  version = var.versionGreen
+}
+`,
+			},
+			expectedProblems: []Problem{
+				{Color: model.Blue, Location: "variables.tf:2", Message: "variable versionBlueCount is never used"},
+				{Color: model.Blue, Location: "main.tf:2", Message: "module blue of output blueHostnames isn't given variable versionBlueCount"},
+			},
+		},
+		{
+			name: "missing_module",
+			files: map[string]string{
+				"variables.tf": contractVariables,
+				"outputs.tf":   contractOutputs,
+				"main.tf": `
+module "blue" {
+  source  = "./modules/info"
+  servers = var.versionBlueCount
+  version = var.versionBlue
+}
+resource "null_resource" "green" {
+  count = var.versionGreenCount
+  triggers = {
+    version = var.versionGreen
+  }
+}
+`,
+			},
+			expectedProblems: []Problem{
+				{Color: model.Green, Location: "outputs.tf:6", Message: "output greenHostnames references missing module green"},
+			},
+		},
+		{
+			name:        "no_files",
+			files:       map[string]string{},
+			expectedErr: errNoConfigFiles,
+		},
+		{
+			name: "invalid_hcl",
+			files: map[string]string{
+				"main.tf": `variable "versionBlue" {`,
+			},
+			expectedErr: errParseProject,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			dir := t.TempDir()
+			for name, data := range test.files {
+				assert.NoError(os.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+			}
+			problems, err := CheckProject(dir, Naming{})
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			for i := range problems {
+				if problems[i].Location != "" {
+					rel, _ := filepath.Rel(dir, problems[i].Location)
+					problems[i].Location = rel
+				}
+			}
+			assert.Equal(test.expectedProblems, problems)
+		})
+	}
+}
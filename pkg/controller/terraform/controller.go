@@ -5,20 +5,35 @@ import (
 	"github.com/xmidt-org/carousel/pkg/model"
 )
 
-func BuildController(config model.BinaryConfig, transitionConfig TerraformTransitionConfig) controller.Controller {
-	clusterGetter := BuildStateDeterminer(config)
-	grapher := BuildClusterGraphRunner(clusterGetter, config)
+func BuildController(config model.BinaryConfig, transitionConfig TerraformTransitionConfig, naming Naming) controller.Controller {
+	// the state and the resources are cached until the carousel invalidates them after an apply or taint.
+	clusterGetter := &cachedCluster{getter: BuildStateDeterminer(config, naming)}
+	listRunner := &cachedRunnable{Runnable: buildListRunner(config)}
+	grapher := &tGraph{getter: clusterGetter, listRunner: listRunner, naming: naming}
 	tainter := BuildTaintHostRunner(grapher, config)
+	transitioner := &tTransition{
+		config:           config,
+		transitionConfig: transitionConfig,
+		naming:           naming,
+		getter:           clusterGetter,
+	}
+	// with an unknown version hosts are tainted, which every version supports.
+	if replacement, _ := ResolveHostReplacement(transitionConfig.Capabilities, transitionConfig.HostReplacement); replacement == ApplyReplacement {
+		transitioner.pending = &controller.PendingReplacements{}
+		tainter = controller.NewReplaceTainter(grapher, transitioner.pending)
+	}
 
 	return struct {
 		controller.WorkspaceSelecter
 		controller.ClusterGetter
 		controller.Tainter
 		controller.ApplyBuilder
+		controller.Cache
 	}{
-		WorkspaceSelecter: BuildSelectWorkspaceRunner(config),
+		WorkspaceSelecter: BuildSelectWorkspaceRunner(config, transitionConfig.Capabilities),
 		ClusterGetter:     clusterGetter,
 		Tainter:           tainter,
-		ApplyBuilder:      BuildTransitioner(config, transitionConfig),
+		ApplyBuilder:      transitioner,
+		Cache:             caches{clusterGetter, listRunner},
 	}
 }
@@ -140,3 +140,15 @@ module.green[1].data.null_data_source.name
 module.green[0].random_id.ID
 module.green[1].random_id.ID
 `
+
+// outputsState builds a state with the outputs and no resources.
+func outputsState(outputs string) string {
+	return `{
+  "version": 4,
+  "terraform_version": "0.13.4",
+  "serial": 1,
+  "lineage": "9abe4427-8f8c-a697-81e5-0bde5a028c73",
+  "outputs": {` + outputs + `},
+  "resources": []
+}`
+}
@@ -6,20 +6,21 @@ import (
 	"github.com/xmidt-org/carousel/pkg/controller"
 	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
-	"regexp"
 	"strconv"
 	"strings"
 )
 
 var (
-	errEmptyHostName  = errors.New("hostname can not be empty")
-	errStateList      = errors.New("failed to list resources")
-	errHostNotInGroup = errors.New("host not part of group")
+	errEmptyHostName   = errors.New("hostname can not be empty")
+	errStateList       = errors.New("failed to list resources")
+	errHostNotInGroup  = errors.New("host not part of group")
+	errNoHostResources = errors.New("no resources found for host")
 )
 
 type tGraph struct {
 	getter     controller.ClusterGetter
 	listRunner runner.Runnable
+	naming     Naming
 }
 
 func (t *tGraph) GetResourcesForHost(hostname string) ([]string, error) {
@@ -30,15 +31,18 @@ func (t *tGraph) GetResourcesForHost(hostname string) ([]string, error) {
 	if err != nil {
 		return []string{}, fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
 	}
+	hostname = strings.TrimSpace(hostname)
 	group := model.Unknown
 	index := 0
+	var host model.Host
 
 LOOP:
 	for color, clusterGroup := range c {
-		for i, host := range clusterGroup.Hosts {
-			if host == strings.TrimSpace(hostname) {
+		for i, fqdn := range clusterGroup.Hosts {
+			if fqdn == hostname {
 				group = color
 				index = i
+				host = clusterGroup.Host(fqdn)
 				break LOOP
 			}
 		}
@@ -51,27 +55,133 @@ LOOP:
 	if err != nil {
 		return []string{}, fmt.Errorf("%w: %v", errStateList, err)
 	}
-	clusterResources := strings.Split(string(listBytes), "\n")
 
-	resourceRegex, err := regexp.Compile(".*?\\[" + strconv.Itoa(index) + "\\].*")
-	if err != nil {
-		return []string{}, err
+	// the key of the host in a count is its index in the hostnames output, in a for_each it is the key of the map.
+	key := strconv.Itoa(index)
+	if host.Key != "" {
+		key = strconv.Quote(host.Key)
 	}
+	module := t.naming.Module(group)
 
 	resources := make([]string, 0)
-	for _, resource := range clusterResources {
-		if resourceRegex.MatchString(resource) {
+	for _, resource := range strings.Split(string(listBytes), "\n") {
+		resource = strings.TrimSpace(resource)
+		if resource == "" {
+			continue
+		}
+		if host.Address != "" {
+			if matchesAddress(resource, host.Address) {
+				resources = append(resources, resource)
+			}
+			continue
+		}
+		if parseAddress(resource).belongsTo(module, key) {
 			resources = append(resources, resource)
 		}
 	}
-
+	if len(resources) == 0 {
+		return resources, fmt.Errorf("%w: %s in module.%s", errNoHostResources, hostname, module)
+	}
 	return resources, nil
 }
 
+// matchesAddress returns true if the resource is the address or is inside of it,
+// module.blue[0].aws_instance.server is inside of module.blue[0].
+func matchesAddress(resource string, address string) bool {
+	return resource == address || strings.HasPrefix(resource, address+".") || strings.HasPrefix(resource, address+"[")
+}
+
+// addressStep is a module or a resource of an address, with the raw key of its instance like 0 or "a".
+type addressStep struct {
+	name string
+	key  string
+}
+
+// address is a resource address from terraform state list, e.g. module.blue["a"].module.server.aws_instance.this[0].
+type address struct {
+	modules  []addressStep
+	resource addressStep
+}
+
+// parseAddress splits an address in its modules and its resource.
+func parseAddress(resource string) address {
+	var a address
+	parts := splitAddress(resource)
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "module" && i+1 < len(parts) {
+			a.modules = append(a.modules, parseStep(parts[i+1]))
+			i++
+			continue
+		}
+		// the resource is what's left, e.g. data.null_data_source.name[0].
+		a.resource = parseStep(strings.Join(parts[i:], "."))
+		break
+	}
+	return a
+}
+
+// belongsTo returns true if the address is in the module of a Color Group and is the instance with the key.
+// The outermost instance key after the module name selects the host, so the key of a module using count or
+// for_each wins over the keys of the resources inside of it.
+func (a address) belongsTo(module string, key string) bool {
+	if len(a.modules) == 0 || a.modules[0].name != module {
+		return false
+	}
+	instanceKey := a.instanceKey()
+	return instanceKey != "" && instanceKey == key
+}
+
+// instanceKey returns the outermost instance key of the address, empty if no module or resource has one.
+func (a address) instanceKey() string {
+	for _, step := range a.modules {
+		if step.key != "" {
+			return step.key
+		}
+	}
+	return a.resource.key
+}
+
+// splitAddress splits on the dots outside of brackets and quotes.
+func splitAddress(resource string) []string {
+	parts := make([]string, 0)
+	depth := 0
+	quoted := false
+	start := 0
+	for i := 0; i < len(resource); i++ {
+		switch c := resource[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			parts = append(parts, resource[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, resource[start:])
+}
+
+func parseStep(part string) addressStep {
+	if open := strings.Index(part, "["); open >= 0 && strings.HasSuffix(part, "]") {
+		return addressStep{name: part[:open], key: part[open+1 : len(part)-1]}
+	}
+	return addressStep{name: part}
+}
+
 // BuildStateDeterminer builds a terraform specific ClusterGraph.
-func BuildClusterGraphRunner(getter controller.ClusterGetter, config model.BinaryConfig) controller.ClusterGraph {
+func BuildClusterGraphRunner(getter controller.ClusterGetter, config model.BinaryConfig, naming Naming) controller.ClusterGraph {
 	return &tGraph{
 		getter:     getter,
-		listRunner: runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "state", "list"),
+		listRunner: buildListRunner(config),
+		naming:     naming,
 	}
 }
+
+func buildListRunner(config model.BinaryConfig) runner.Runnable {
+	return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "state", "list")
+}
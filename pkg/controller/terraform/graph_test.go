@@ -18,6 +18,45 @@ var countListRunner = simplerunnable{
 	Data: []byte(countGoodList),
 }
 
+// bothGroupsState has two hosts in each group, with the same indexes.
+var bothGroupsState = outputsState(`
+    "blueHostnames": {"value": ["blue-0.example.com", "blue-1.example.com"], "type": ["tuple", ["string", "string"]]},
+    "blueVersion": {"value": "0.10.0", "type": "string"},
+    "greenHostnames": {"value": ["green-0.example.com", "green-1.example.com"], "type": ["tuple", ["string", "string"]]},
+    "greenVersion": {"value": "0.11.0", "type": "string"}`)
+
+const bothGroupsList = `module.blue.random_id.ID[0]
+module.blue.random_id.ID[1]
+module.green.random_id.ID[0]
+module.green.random_id.ID[1]
+module.greenery.random_id.ID[1]
+random_id.shared[1]
+`
+
+// forEachState has the hosts of blue keyed by the keys of a for_each.
+var forEachState = outputsState(`
+    "blueHostnames": {"value": {"a": "a.example.com", "a.b": "ab.example.com"}, "type": ["map", "string"]},
+    "blueVersion": {"value": "0.10.0", "type": "string"}`)
+
+const forEachList = `module.blue["a"].aws_instance.server
+module.blue["a"].module.disk.aws_ebs_volume.this[0]
+module.blue["a.b"].aws_instance.server
+module.blue.module.server["a"].aws_instance.this[0]
+module.blue.module.server["a.b"].aws_instance.this[0]
+module.green["a"].aws_instance.server
+`
+
+// addressState has hosts pointing at the address of their resources.
+var addressState = outputsState(`
+    "blueHostnames": {"value": [{"fqdn": "a.example.com", "address": "aws_instance.server[0]"}, {"fqdn": "b.example.com", "address": "module.servers[1]"}], "type": ["tuple", [["object", {"fqdn": "string", "address": "string"}], ["object", {"fqdn": "string", "address": "string"}]]]},
+    "blueVersion": {"value": "0.10.0", "type": "string"}`)
+
+const addressList = `aws_instance.server[0]
+aws_instance.server[10]
+module.servers[1].aws_instance.this
+module.servers[10].aws_instance.this
+`
+
 func TestClusterGraph(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -25,6 +64,7 @@ func TestClusterGraph(t *testing.T) {
 		expectedResources []string
 		staterunner       runner.Runnable
 		graphrunner       runner.Runnable
+		naming            NamingConfig
 		expectedErr       error
 	}{
 		{
@@ -110,6 +150,64 @@ func TestClusterGraph(t *testing.T) {
 			graphrunner: simpleListRunner,
 			expectedErr: errEmptyHostName,
 		},
+		{
+			name:              "both_groups/blue",
+			searchHost:        "blue-1.example.com",
+			expectedResources: []string{"module.blue.random_id.ID[1]"},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(bothGroupsState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(bothGroupsList)},
+		},
+		{
+			name:              "both_groups/green",
+			searchHost:        "green-0.example.com",
+			expectedResources: []string{"module.green.random_id.ID[0]"},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(bothGroupsState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(bothGroupsList)},
+		},
+		{
+			name:              "both_groups/custom_module",
+			searchHost:        "green-1.example.com",
+			expectedResources: []string{"module.greenery.random_id.ID[1]"},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(bothGroupsState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(bothGroupsList)},
+			naming:            NamingConfig{Module: "{{.Name}}ery"},
+		},
+		{
+			name:              "for_each/module",
+			searchHost:        "a.example.com",
+			expectedResources: []string{`module.blue["a"].aws_instance.server`, `module.blue["a"].module.disk.aws_ebs_volume.this[0]`, `module.blue.module.server["a"].aws_instance.this[0]`},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(forEachState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(forEachList)},
+		},
+		{
+			name:              "for_each/dotted_key",
+			searchHost:        "ab.example.com",
+			expectedResources: []string{`module.blue["a.b"].aws_instance.server`, `module.blue.module.server["a.b"].aws_instance.this[0]`},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(forEachState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(forEachList)},
+		},
+		{
+			name:              "address/resource",
+			searchHost:        "a.example.com",
+			expectedResources: []string{"aws_instance.server[0]"},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(addressState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(addressList)},
+		},
+		{
+			name:              "address/module",
+			searchHost:        "b.example.com",
+			expectedResources: []string{"module.servers[1].aws_instance.this"},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(addressState)},
+			graphrunner:       simplerunnable{Name: "list", Data: []byte(addressList)},
+		},
+		{
+			name:              "no_resources",
+			searchHost:        "blue-0.example.com",
+			expectedResources: []string{},
+			staterunner:       simplerunnable{Name: "testRunner", Data: []byte(bothGroupsState)},
+			graphrunner:       simpleListRunner,
+			expectedErr:       errNoHostResources,
+		},
 		{
 			name:              "clean_state_failed_graphbuilder",
 			searchHost:        "carousel-demo-ffdbb6.example.com",
@@ -128,11 +226,14 @@ func TestClusterGraph(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			assert := assert.New(t)
+			naming, err := BuildNaming(test.naming)
+			assert.NoError(err)
 			stateGetter := &tState{stateRunner: test.staterunner}
 
 			graphGetter := tGraph{
 				getter:     stateGetter,
 				listRunner: test.graphrunner,
+				naming:     naming,
 			}
 			resource, err := graphGetter.GetResourcesForHost(test.searchHost)
 			if test.expectedErr != nil {
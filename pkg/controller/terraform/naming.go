@@ -0,0 +1,164 @@
+package terraform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+var (
+	errInvalidNaming = errors.New("invalid naming template")
+)
+
+const (
+	defaultCountVariable   = "version{{.Title}}Count"
+	defaultVersionVariable = "version{{.Title}}"
+	defaultHostnamesOutput = "{{.Name}}Hostnames"
+	defaultVersionOutput   = "{{.Name}}Version"
+	defaultModule          = "{{.Name}}"
+)
+
+// NamingConfig configures the names of the terraform variables and outputs of each Color Group.
+// Each value is a go template where .Name is the name of the Color Group and .Title is the name
+// with the first letter upper cased. The functions title, upper and lower are also available.
+type NamingConfig struct {
+	// CountVariable is the variable holding the number of nodes of a group.
+	// (Optional): default version{{.Title}}Count
+	CountVariable string
+
+	// VersionVariable is the variable holding the version of a group.
+	// (Optional): default version{{.Title}}
+	VersionVariable string
+
+	// HostnamesOutput is the output listing the hostnames of a group.
+	// (Optional): default {{.Name}}Hostnames
+	HostnamesOutput string
+
+	// VersionOutput is the output holding the version of a group.
+	// (Optional): default {{.Name}}Version
+	VersionOutput string
+
+	// Module is the module holding the resources of a group, the resources of a failing host are searched in it.
+	// (Optional): default {{.Name}}
+	Module string
+}
+
+// Naming builds the terraform variable and output names of each Color Group.
+// The zero value uses the default names.
+type Naming struct {
+	countVariable   *template.Template
+	versionVariable *template.Template
+	hostnamesOutput *template.Template
+	versionOutput   *template.Template
+	module          *template.Template
+}
+
+type namingData struct {
+	Name  string
+	Title string
+}
+
+var namingFuncs = template.FuncMap{
+	"title": title,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// BuildNaming parses the templates of the NamingConfig.
+// An error is returned if a template can't be parsed or executed.
+func BuildNaming(config NamingConfig) (Naming, error) {
+	var (
+		naming Naming
+		err    error
+	)
+	if naming.countVariable, err = parseNamingTemplate("countVariable", config.CountVariable, defaultCountVariable); err != nil {
+		return Naming{}, err
+	}
+	if naming.versionVariable, err = parseNamingTemplate("versionVariable", config.VersionVariable, defaultVersionVariable); err != nil {
+		return Naming{}, err
+	}
+	if naming.hostnamesOutput, err = parseNamingTemplate("hostnamesOutput", config.HostnamesOutput, defaultHostnamesOutput); err != nil {
+		return Naming{}, err
+	}
+	if naming.versionOutput, err = parseNamingTemplate("versionOutput", config.VersionOutput, defaultVersionOutput); err != nil {
+		return Naming{}, err
+	}
+	if naming.module, err = parseNamingTemplate("module", config.Module, defaultModule); err != nil {
+		return Naming{}, err
+	}
+	return naming, nil
+}
+
+func parseNamingTemplate(name string, text string, defaultText string) (*template.Template, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New(name).Funcs(namingFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %v", errInvalidNaming, name, err)
+	}
+	// make sure the template can be executed, so names can be built later without an error.
+	result, err := execute(tmpl, model.Blue)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %v", errInvalidNaming, name, err)
+	}
+	if result == "" {
+		return nil, fmt.Errorf("%w: %s results in an empty name", errInvalidNaming, name)
+	}
+	return tmpl, nil
+}
+
+// CountVariable returns the name of the variable holding the number of nodes of a Color Group.
+func (n Naming) CountVariable(color model.Color) string {
+	return n.name(n.countVariable, defaultCountVariable, color)
+}
+
+// VersionVariable returns the name of the variable holding the version of a Color Group.
+func (n Naming) VersionVariable(color model.Color) string {
+	return n.name(n.versionVariable, defaultVersionVariable, color)
+}
+
+// HostnamesOutput returns the name of the output listing the hostnames of a Color Group.
+func (n Naming) HostnamesOutput(color model.Color) string {
+	return n.name(n.hostnamesOutput, defaultHostnamesOutput, color)
+}
+
+// VersionOutput returns the name of the output holding the version of a Color Group.
+func (n Naming) VersionOutput(color model.Color) string {
+	return n.name(n.versionOutput, defaultVersionOutput, color)
+}
+
+// Module returns the name of the module holding the resources of a Color Group, e.g. module.blue.
+func (n Naming) Module(color model.Color) string {
+	return n.name(n.module, defaultModule, color)
+}
+
+func (n Naming) name(tmpl *template.Template, defaultText string, color model.Color) string {
+	if tmpl == nil {
+		tmpl = template.Must(template.New("default").Funcs(namingFuncs).Parse(defaultText))
+	}
+	// templates are checked by BuildNaming, so an error can't occur.
+	result, _ := execute(tmpl, color)
+	return result
+}
+
+func execute(tmpl *template.Template, color model.Color) (string, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, namingData{
+		Name:  color.String(),
+		Title: title(color.String()),
+	})
+	return strings.TrimSpace(buf.String()), err
+}
+
+// title upper cases the first letter of s, so blue becomes Blue.
+func title(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strings"
+	"testing"
+)
+
+const customNamingState = `{
+  "version": 4,
+  "terraform_version": "0.13.4",
+  "serial": 1002,
+  "lineage": "9abe4427-8f8c-a697-81e5-0bde5a028c73",
+  "outputs": {
+    "hosts_blue": {
+      "value": ["carousel-demo-ffdbb6.example.com"],
+      "type": ["tuple", ["string"]]
+    },
+    "version_blue": {
+      "value": "0.10.0",
+      "type": "string"
+    }
+  },
+  "resources": []
+}`
+
+func TestBuildNaming(t *testing.T) {
+	tests := []struct {
+		name                    string
+		config                  NamingConfig
+		expectedCountVariable   string
+		expectedVersionVariable string
+		expectedHostnamesOutput string
+		expectedVersionOutput   string
+		expectedModule          string
+		expectedErr             error
+	}{
+		{
+			name:                    "default",
+			expectedCountVariable:   "versionBlueCount",
+			expectedVersionVariable: "versionBlue",
+			expectedHostnamesOutput: "blueHostnames",
+			expectedVersionOutput:   "blueVersion",
+			expectedModule:          "blue",
+		},
+		{
+			name: "custom",
+			config: NamingConfig{
+				CountVariable:   "{{.Name}}_count",
+				VersionVariable: "{{upper .Name}}_VERSION",
+				HostnamesOutput: "hosts_{{.Name}}",
+				VersionOutput:   "version_{{lower .Title}}",
+				Module:          "{{.Name}}_servers",
+			},
+			expectedCountVariable:   "blue_count",
+			expectedVersionVariable: "BLUE_VERSION",
+			expectedHostnamesOutput: "hosts_blue",
+			expectedVersionOutput:   "version_blue",
+			expectedModule:          "blue_servers",
+		},
+		{
+			name: "bad_template",
+			config: NamingConfig{
+				CountVariable: "{{.Name",
+			},
+			expectedErr: errInvalidNaming,
+		},
+		{
+			name: "unknown_field",
+			config: NamingConfig{
+				VersionOutput: "{{.Color}}Version",
+			},
+			expectedErr: errInvalidNaming,
+		},
+		{
+			name: "empty_result",
+			config: NamingConfig{
+				HostnamesOutput: "{{if false}}{{.Name}}{{end}}",
+			},
+			expectedErr: errInvalidNaming,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			naming, err := BuildNaming(test.config)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedCountVariable, naming.CountVariable(model.Blue))
+			assert.Equal(test.expectedVersionVariable, naming.VersionVariable(model.Blue))
+			assert.Equal(test.expectedHostnamesOutput, naming.HostnamesOutput(model.Blue))
+			assert.Equal(test.expectedVersionOutput, naming.VersionOutput(model.Blue))
+			assert.Equal(test.expectedModule, naming.Module(model.Blue))
+		})
+	}
+}
+
+func TestZeroNaming(t *testing.T) {
+	assert := assert.New(t)
+	naming := Naming{}
+	assert.Equal("versionGreenCount", naming.CountVariable(model.Green))
+	assert.Equal("versionGreen", naming.VersionVariable(model.Green))
+	assert.Equal("greenHostnames", naming.HostnamesOutput(model.Green))
+	assert.Equal("greenVersion", naming.VersionOutput(model.Green))
+	assert.Equal("green", naming.Module(model.Green))
+}
+
+func TestNamingIsUsed(t *testing.T) {
+	assert := assert.New(t)
+	naming, err := BuildNaming(NamingConfig{
+		CountVariable:   "{{.Name}}_count",
+		VersionVariable: "{{.Name}}_version",
+		HostnamesOutput: "hosts_{{.Name}}",
+		VersionOutput:   "version_{{.Name}}",
+	})
+	assert.NoError(err)
+
+	stateGetter := tState{
+		stateRunner: simplerunnable{
+			Name: "testRunner",
+			Data: []byte(customNamingState),
+		},
+		naming: naming,
+	}
+	cluster, err := stateGetter.GetCluster()
+	assert.NoError(err)
+	assert.Equal([]string{"carousel-demo-ffdbb6.example.com"}, cluster[model.Blue].Hosts)
+	assert.Equal(semver.MustParse("0.10.0"), cluster[model.Blue].Version)
+
+	transitioner := BuildTransitioner(model.BinaryConfig{}, TerraformTransitionConfig{}, naming)
+	applyRunner := transitioner.CreateApply(model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+		model.Green: model.ClusterGroupState{Count: 2, Version: semver.MustParse("0.11.0")},
+	}, model.Step{model.Blue: 1, model.Green: 2})
+	assert.True(strings.Contains(applyRunner.String(), "-var blue_count=1 -var blue_version=0.10.0 -var green_count=2 -var green_version=0.11.0"), applyRunner.String())
+}
@@ -0,0 +1,185 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	errPlanFailure    = errors.New("failed to plan step")
+	errReadPlan       = errors.New("failed to read plan")
+	errUnexpectedPlan = errors.New("plan has unexpected changes")
+)
+
+// planFileName is shown in place of the temporary plan file.
+const planFileName = "<plan>"
+
+// plan is the part of terraform show -json of a plan file carousel checks.
+type plan struct {
+	ResourceChanges []resourceChange `json:"resource_changes"`
+}
+
+type resourceChange struct {
+	Address string `json:"address"`
+	Change  struct {
+		Actions []string `json:"actions"`
+	} `json:"change"`
+}
+
+// action returns create, destroy or replace, or an empty string if no resource is created or destroyed.
+func (r resourceChange) action() string {
+	create, destroy := false, false
+	for _, action := range r.Change.Actions {
+		switch action {
+		case "create":
+			create = true
+		case "delete":
+			destroy = true
+		}
+	}
+	switch {
+	case create && destroy:
+		return "replace"
+	case create:
+		return "create"
+	case destroy:
+		return "destroy"
+	}
+	return ""
+}
+
+// planApply plans the step to a plan file, checks the changes of the plan and applies that exact plan file.
+type planApply struct {
+	getter controller.ClusterGetter
+	naming Naming
+	step   model.Step
+	// plan, show and apply build the Runnables of the plan file.
+	plan  func(planFile string) runner.Runnable
+	show  func(planFile string) runner.Runnable
+	apply func(planFile string) runner.Runnable
+}
+
+func (p *planApply) Output() ([]byte, error) {
+	// the plan is checked against the cluster before the step.
+	cluster, err := p.getter.GetCluster()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", controller.ErrGetClusterFailure, err)
+	}
+
+	dir, err := os.MkdirTemp("", "carousel-plan")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errPlanFailure, err)
+	}
+	defer os.RemoveAll(dir)
+	planFile := filepath.Join(dir, "step.tfplan")
+
+	if data, err := p.plan(planFile).Output(); err != nil {
+		return data, fmt.Errorf("%w: %v", errPlanFailure, err)
+	}
+	data, err := p.show(planFile).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errReadPlan, err)
+	}
+	var changes plan
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", errReadPlan, err)
+	}
+	if problems := checkPlan(changes, cluster, p.step, p.naming); len(problems) > 0 {
+		return nil, fmt.Errorf("%w, nothing was applied:\n  %s", errUnexpectedPlan, strings.Join(problems, "\n  "))
+	}
+	return p.apply(planFile).Output()
+}
+
+func (p *planApply) String() string {
+	return strings.Join([]string{p.plan(planFileName).String(), p.show(planFileName).String(), p.apply(planFileName).String()}, " && ")
+}
+
+// checkPlan returns the changes of the plan that don't match the step, as readable lines.
+// Resources can only be created or destroyed in the module of a Color Group, each Color Group must create or
+// destroy as many hosts as its count changes. A host is the instance key of the resources, like module.blue[1] or
+// module.blue.aws_instance.server["a"]. A replacement inside a Color Group is always expected, it comes from a new
+// version or a host being replaced.
+func checkPlan(changes plan, cluster model.Cluster, step model.Step, naming Naming) []string {
+	problems := make([]string, 0)
+	created := map[model.Color]map[string][]string{}
+	destroyed := map[model.Color]map[string][]string{}
+	for _, change := range changes.ResourceChanges {
+		action := change.action()
+		if action == "" {
+			continue
+		}
+		color, ok := groupOf(change.Address, naming)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s %s: outside of the groups", action, change.Address))
+			continue
+		}
+		key := parseAddress(change.Address).instanceKey()
+		if key == "" {
+			continue
+		}
+		switch action {
+		case "create":
+			addKey(created, color, key, change.Address)
+		case "destroy":
+			addKey(destroyed, color, key, change.Address)
+		}
+	}
+
+	for _, color := range model.ValidColors {
+		delta := step[color] - len(cluster[color].Hosts)
+		expectedCreated, expectedDestroyed := 0, 0
+		if delta > 0 {
+			expectedCreated = delta
+		} else {
+			expectedDestroyed = -delta
+		}
+		problems = append(problems, checkHostChanges(color, "creates", created[color], expectedCreated)...)
+		problems = append(problems, checkHostChanges(color, "destroys", destroyed[color], expectedDestroyed)...)
+	}
+	return problems
+}
+
+func checkHostChanges(color model.Color, verb string, hosts map[string][]string, expected int) []string {
+	if len(hosts) == expected {
+		return nil
+	}
+	keys := make([]string, 0, len(hosts))
+	for key := range hosts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	problems := []string{fmt.Sprintf("%s %s %d hosts, expected %d", color, verb, len(hosts), expected)}
+	for _, key := range keys {
+		problems = append(problems, fmt.Sprintf("  [%s] %s", key, strings.Join(hosts[key], ", ")))
+	}
+	return problems
+}
+
+func addKey(hosts map[model.Color]map[string][]string, color model.Color, key string, resource string) {
+	if hosts[color] == nil {
+		hosts[color] = map[string][]string{}
+	}
+	hosts[color][key] = append(hosts[color][key], resource)
+}
+
+// groupOf returns the Color Group of the module the resource is in.
+func groupOf(resource string, naming Naming) (model.Color, bool) {
+	a := parseAddress(resource)
+	if len(a.modules) == 0 {
+		return model.Unknown, false
+	}
+	for _, color := range model.ValidColors {
+		if a.modules[0].name == naming.Module(color) {
+			return color, true
+		}
+	}
+	return model.Unknown, false
+}
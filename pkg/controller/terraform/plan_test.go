@@ -0,0 +1,219 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"strings"
+	"testing"
+)
+
+// planJSON builds the terraform show -json output of a plan with the changes, given as address=action.
+func planJSON(changes ...string) string {
+	resources := make([]string, 0, len(changes))
+	for _, change := range changes {
+		parts := strings.SplitN(change, "=", 2)
+		actions := `"` + strings.Join(strings.Split(parts[1], ","), `", "`) + `"`
+		resources = append(resources, `{"address": `+quoteJSON(parts[0])+`, "change": {"actions": [`+actions+`]}}`)
+	}
+	return `{"format_version": "1.0", "resource_changes": [` + strings.Join(resources, ", ") + `]}`
+}
+
+func quoteJSON(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+func TestCheckPlan(t *testing.T) {
+	cluster := model.Cluster{
+		model.Blue:  model.ClusterGroup{Hosts: []string{"blue-0.example.com", "blue-1.example.com"}, Version: semver.MustParse("0.10.0")},
+		model.Green: model.ClusterGroup{Hosts: []string{}, Version: semver.MustParse("0.0.0")},
+	}
+	tests := []struct {
+		name             string
+		plan             string
+		step             model.Step
+		naming           NamingConfig
+		expectedProblems []string
+	}{
+		{
+			name: "expected",
+			plan: planJSON(
+				"module.blue.random_id.ID[1]=delete",
+				"module.blue.data.null_data_source.name[1]=delete",
+				"module.green.random_id.ID[0]=create",
+				"module.green.aws_security_group.group=create",
+				"aws_lb.shared=update",
+				"module.blue.random_id.ID[0]=no-op",
+			),
+			step:             model.Step{model.Blue: 1, model.Green: 1},
+			expectedProblems: []string{},
+		},
+		{
+			name:             "replace_in_group",
+			plan:             planJSON(`module.blue["a"].aws_instance.server=delete,create`),
+			step:             model.Step{model.Blue: 2, model.Green: 0},
+			expectedProblems: []string{},
+		},
+		{
+			name:             "shared_destroy",
+			plan:             planJSON("aws_lb.shared=delete", "aws_route53_zone.main=create,delete"),
+			step:             model.Step{model.Blue: 2, model.Green: 0},
+			expectedProblems: []string{"destroy aws_lb.shared: outside of the groups", "replace aws_route53_zone.main: outside of the groups"},
+		},
+		{
+			name: "too_many_hosts",
+			plan: planJSON(
+				"module.green.random_id.ID[0]=create",
+				"module.green.random_id.ID[1]=create",
+				"module.green.aws_instance.server[1]=create",
+			),
+			step: model.Step{model.Blue: 2, model.Green: 1},
+			expectedProblems: []string{
+				"green creates 2 hosts, expected 1",
+				"  [0] module.green.random_id.ID[0]",
+				"  [1] module.green.random_id.ID[1], module.green.aws_instance.server[1]",
+			},
+		},
+		{
+			name:             "missing_destroy",
+			plan:             planJSON(),
+			step:             model.Step{model.Blue: 0, model.Green: 0},
+			expectedProblems: []string{"blue destroys 0 hosts, expected 2"},
+		},
+		{
+			name:             "wrong_group",
+			plan:             planJSON("module.blue.random_id.ID[1]=delete"),
+			step:             model.Step{model.Blue: 2, model.Green: 0},
+			expectedProblems: []string{"blue destroys 1 hosts, expected 0", "  [1] module.blue.random_id.ID[1]"},
+		},
+		{
+			name:             "custom_module",
+			plan:             planJSON("module.green_servers[0].aws_instance.server=create"),
+			step:             model.Step{model.Blue: 2, model.Green: 1},
+			naming:           NamingConfig{Module: "{{.Name}}_servers"},
+			expectedProblems: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			naming, err := BuildNaming(test.naming)
+			assert.NoError(err)
+			var changes plan
+			assert.NoError(json.Unmarshal([]byte(test.plan), &changes))
+			assert.Equal(test.expectedProblems, checkPlan(changes, cluster, test.step, naming))
+		})
+	}
+}
+
+// fileRunnable records the plan file it was built with.
+type fileRunnable struct {
+	name     string
+	data     []byte
+	err      error
+	calls    *[]string
+	planFile string
+}
+
+func (f fileRunnable) Output() ([]byte, error) {
+	*f.calls = append(*f.calls, f.name)
+	return f.data, f.err
+}
+
+func (f fileRunnable) String() string {
+	return f.name + " " + f.planFile
+}
+
+func TestPlanApply(t *testing.T) {
+	planErr := errors.New("plan failed")
+	tests := []struct {
+		name          string
+		state         string
+		plan          string
+		planErr       error
+		expectedCalls []string
+		expectedErr   error
+	}{
+		{
+			name:          "applied",
+			state:         cleanState,
+			plan:          planJSON("module.green.random_id.ID[1]=delete"),
+			expectedCalls: []string{"plan", "show", "apply"},
+		},
+		{
+			name:          "unexpected",
+			state:         cleanState,
+			plan:          planJSON("aws_lb.shared=delete"),
+			expectedCalls: []string{"plan", "show"},
+			expectedErr:   errUnexpectedPlan,
+		},
+		{
+			name:          "plan_failure",
+			state:         cleanState,
+			planErr:       planErr,
+			expectedCalls: []string{"plan"},
+			expectedErr:   errPlanFailure,
+		},
+		{
+			name:          "bad_plan",
+			state:         cleanState,
+			plan:          "not json",
+			expectedCalls: []string{"plan", "show"},
+			expectedErr:   errReadPlan,
+		},
+		{
+			name:          "no_cluster",
+			expectedCalls: []string{},
+			expectedErr:   controller.ErrGetClusterFailure,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			calls := make([]string, 0)
+			planFiles := map[string]bool{}
+			build := func(name string, data string, err error) func(planFile string) runner.Runnable {
+				return func(planFile string) runner.Runnable {
+					planFiles[planFile] = true
+					return fileRunnable{name: name, data: []byte(data), err: err, calls: &calls, planFile: planFile}
+				}
+			}
+			var stateData []byte
+			if test.state != "" {
+				stateData = []byte(test.state)
+			}
+			apply := &planApply{
+				getter: &tState{stateRunner: simplerunnable{Name: "state", Data: stateData}},
+				step:   model.Step{model.Blue: 0, model.Green: 1},
+				plan:   build("plan", "", test.planErr),
+				show:   build("show", test.plan, nil),
+				apply:  build("apply", "applied", nil),
+			}
+			data, err := apply.Output()
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+			} else {
+				assert.NoError(err)
+				assert.Equal("applied", string(data))
+			}
+			// the same plan file is planned, shown and applied.
+			assert.LessOrEqual(len(planFiles), 1)
+			assert.Equal(test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestSafeModeApply(t *testing.T) {
+	assert := assert.New(t)
+	transition := &tTransition{naming: Naming{}, transitionConfig: TerraformTransitionConfig{SafeMode: true}}
+	apply := transition.CreateApply(model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("0.11.0")},
+	}, model.Step{model.Blue: 0, model.Green: 1})
+	assert.Equal("terraform plan -out=<plan> -var versionBlueCount=0 -var versionBlue=0.10.0 -var versionGreenCount=1 -var versionGreen=0.11.0"+
+		" && terraform show -json <plan> && terraform apply <plan>", apply.String())
+}
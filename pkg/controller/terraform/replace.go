@@ -0,0 +1,55 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errUnknownHostReplacement = errors.New("unknown host replacement")
+
+// HostReplacement is how the resources of a host failing validation are replaced.
+type HostReplacement string
+
+const (
+	// AutoReplacement uses ApplyReplacement if the terraform version supports it, otherwise TaintReplacement.
+	AutoReplacement HostReplacement = "auto"
+	// TaintReplacement runs terraform taint for each resource of the host, before the apply is run again.
+	TaintReplacement HostReplacement = "taint"
+	// ApplyReplacement passes the resources of the host as -replace to the next apply.
+	ApplyReplacement HostReplacement = "replace"
+)
+
+// ParseHostReplacement parses the name of a HostReplacement, empty is AutoReplacement.
+func ParseHostReplacement(name string) (HostReplacement, error) {
+	switch replacement := HostReplacement(strings.ToLower(name)); replacement {
+	case "":
+		return AutoReplacement, nil
+	case AutoReplacement, TaintReplacement, ApplyReplacement:
+		return replacement, nil
+	default:
+		return "", fmt.Errorf("%w: %s, try [auto, taint, replace]", errUnknownHostReplacement, name)
+	}
+}
+
+// ResolveHostReplacement chooses the HostReplacement of AutoReplacement by the Capabilities of the binary,
+// an unknown binary uses TaintReplacement. ApplyReplacement fails if the binary is known to not support it.
+func ResolveHostReplacement(capabilities Capabilities, replacement HostReplacement) (HostReplacement, error) {
+	switch replacement {
+	case AutoReplacement:
+		if capabilities.Replace {
+			return ApplyReplacement, nil
+		}
+		return TaintReplacement, nil
+	case ApplyReplacement:
+		if capabilities.Product != "" && !capabilities.Replace {
+			return TaintReplacement, fmt.Errorf("%w: %s doesn't support apply -replace, it needs terraform %s or newer", ErrUnsupportedVersion, capabilities, replaceVersion)
+		}
+	}
+	return replacement, nil
+}
+
+// replaceFlag passes a resource as -replace to the apply.
+func replaceFlag(resource string) []string {
+	return []string{"-replace=" + resource}
+}
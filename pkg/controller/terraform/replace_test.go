@@ -0,0 +1,48 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strings"
+	"testing"
+)
+
+func TestParseHostReplacement(t *testing.T) {
+	tests := []struct {
+		name                string
+		expectedReplacement HostReplacement
+		expectedErr         error
+	}{
+		{name: "", expectedReplacement: AutoReplacement},
+		{name: "auto", expectedReplacement: AutoReplacement},
+		{name: "Taint", expectedReplacement: TaintReplacement},
+		{name: "replace", expectedReplacement: ApplyReplacement},
+		{name: "recreate", expectedErr: errUnknownHostReplacement},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			replacement, err := ParseHostReplacement(test.name)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedReplacement, replacement)
+		})
+	}
+}
+
+func TestReplaceApplyArgs(t *testing.T) {
+	assert := assert.New(t)
+	pending := &controller.PendingReplacements{}
+	pending.Add([]string{"module.blue.random_id.ID[0]"})
+	transition := &tTransition{naming: Naming{}, pending: pending}
+	apply := transition.CreateApply(model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Count: 1},
+		model.Green: model.ClusterGroupState{Count: 0},
+	}, model.Step{model.Blue: 1, model.Green: 0})
+	assert.True(strings.HasPrefix(apply.String(), "terraform apply --auto-approve -replace=module.blue.random_id.ID[0] -var versionBlueCount=1"), apply.String())
+}
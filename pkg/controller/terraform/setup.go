@@ -15,6 +15,9 @@ type tSelectWorkspace struct {
 	listRunner            runner.Runnable
 	selectWorkspaceRunner func(workspace string) runner.Runnable
 	newWorkspaceRunner    func(workspace string) runner.Runnable
+	deleteWorkspaceRunner func(workspace string, force bool) runner.Runnable
+	// create creates a missing workspace when it is selected, otherwise selecting it fails.
+	create bool
 }
 
 var (
@@ -23,35 +26,37 @@ var (
 	errListWorkspaceFailure   = errors.New("failed to list workspace")
 	errSelectWorkspaceFailure = errors.New("failed to select workspace")
 	errCreateWorkspaceFailure = errors.New("failed to create workspace")
+	errDeleteWorkspaceFailure = errors.New("failed to delete workspace")
+	errWorkspaceExists        = errors.New("workspace already exists")
+	errEmptyWorkspace         = errors.New("workspace can not be empty")
 )
 
 func (t *tSelectWorkspace) SelectWorkspace(workspace string) error {
-	_, err := t.initRunner.Output()
-	if err != nil {
-		return fmt.Errorf("%w: %v", errInitWorkspaceFailure, err)
+	if err := t.init(); err != nil {
+		return err
 	}
 	if workspace == "" {
 		// if workspace is empty use what is currently selected
 		return nil
 	}
 
-	data, err := t.showRunner.Output()
+	current, err := t.CurrentWorkspace()
 	if err != nil {
-		return fmt.Errorf("%w: %v", errShowWorkspaceFailure, err)
+		return err
 	}
 	// already in current workspace
-	if strings.TrimSpace(string(data)) == workspace {
+	if current == workspace {
 		return nil
 	}
 
 	// does the workspace exist?
-	data, err = t.listRunner.Output()
+	exists, err := t.exists(workspace)
 	if err != nil {
-		return fmt.Errorf("%w: %v", errListWorkspaceFailure, err)
+		return err
 	}
 
 	// select workspace
-	if strings.Contains(string(data), workspace) {
+	if exists {
 		// switch workspace
 		_, err = t.selectWorkspaceRunner(workspace).Output()
 		if err != nil {
@@ -60,6 +65,9 @@ func (t *tSelectWorkspace) SelectWorkspace(workspace string) error {
 		return nil
 	}
 
+	if !t.create {
+		return fmt.Errorf("%w: %s", controller.ErrWorkspaceNotFound, workspace)
+	}
 	// create workspace
 	_, err = t.newWorkspaceRunner(workspace).Output()
 	if err != nil {
@@ -68,17 +76,162 @@ func (t *tSelectWorkspace) SelectWorkspace(workspace string) error {
 	return nil
 }
 
+func (t *tSelectWorkspace) ListWorkspaces() ([]string, error) {
+	if err := t.init(); err != nil {
+		return nil, err
+	}
+	data, err := t.listRunner.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errListWorkspaceFailure, err)
+	}
+	return parseWorkspaces(data), nil
+}
+
+func (t *tSelectWorkspace) CurrentWorkspace() (string, error) {
+	if err := t.init(); err != nil {
+		return "", err
+	}
+	data, err := t.showRunner.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errShowWorkspaceFailure, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (t *tSelectWorkspace) NewWorkspace(workspace string) error {
+	if workspace == "" {
+		return errEmptyWorkspace
+	}
+	exists, err := t.exists(workspace)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", errWorkspaceExists, workspace)
+	}
+	if _, err := t.newWorkspaceRunner(workspace).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errCreateWorkspaceFailure, err, workspace)
+	}
+	return nil
+}
+
+func (t *tSelectWorkspace) DeleteWorkspace(workspace string, force bool) error {
+	if workspace == "" {
+		return errEmptyWorkspace
+	}
+	exists, err := t.exists(workspace)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", controller.ErrWorkspaceNotFound, workspace)
+	}
+	if _, err := t.deleteWorkspaceRunner(workspace, force).Output(); err != nil {
+		return fmt.Errorf("%v: %w for %s", errDeleteWorkspaceFailure, err, workspace)
+	}
+	return nil
+}
+
+// exists returns true if the workspace is listed, names must match exactly so prod doesn't match prod-east.
+func (t *tSelectWorkspace) exists(workspace string) (bool, error) {
+	workspaces, err := t.ListWorkspaces()
+	if err != nil {
+		return false, err
+	}
+	for _, name := range workspaces {
+		if name == workspace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (t *tSelectWorkspace) init() error {
+	if _, err := t.initRunner.Output(); err != nil {
+		return fmt.Errorf("%w: %v", errInitWorkspaceFailure, err)
+	}
+	return nil
+}
+
 // BuildStateDeterminer builds a terraform specific WorkspaceSelecter.
-func BuildSelectWorkspaceRunner(config model.BinaryConfig) controller.WorkspaceSelecter {
+// A missing workspace is created with workspace select -or-create if the binary supports it, otherwise workspace new.
+func BuildSelectWorkspaceRunner(config model.BinaryConfig, capabilities Capabilities) controller.WorkspaceSelecter {
+	return BuildWorkspaceManager(config, capabilities, true)
+}
+
+// BuildWorkspaceManager builds a terraform specific WorkspaceManager, init runs once before the first operation.
+// If create is false, selecting a missing workspace fails instead of creating it.
+func BuildWorkspaceManager(config model.BinaryConfig, capabilities Capabilities, create bool) controller.WorkspaceManager {
 	return &tSelectWorkspace{
-		initRunner: runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "init"),
+		// init only runs again if it failed.
+		initRunner: &cachedRunnable{Runnable: buildInitRunner(config)},
 		showRunner: runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}.WithSuppressErrOutput(true), "workspace", "show"),
 		listRunner: runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}.WithSuppressErrOutput(true), "workspace", "list"),
 		selectWorkspaceRunner: func(workspace string) runner.Runnable {
 			return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "workspace", "select", workspace)
 		},
 		newWorkspaceRunner: func(workspace string) runner.Runnable {
+			if capabilities.SelectOrCreate {
+				return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "workspace", "select", "-or-create=true", workspace)
+			}
 			return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "workspace", "new", workspace)
 		},
+		deleteWorkspaceRunner: func(workspace string, force bool) runner.Runnable {
+			if force {
+				return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "workspace", "delete", "-force", workspace)
+			}
+			return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, "workspace", "delete", workspace)
+		},
+		create: create,
+	}
+}
+
+// buildInitRunner builds terraform init with the backend configuration and flags of the InitConfig.
+func buildInitRunner(config model.BinaryConfig) runner.Runnable {
+	cmdArgs := []string{"init"}
+	for _, file := range config.Init.BackendConfigFiles {
+		cmdArgs = append(cmdArgs, "-backend-config="+file)
+	}
+	for _, pair := range config.Init.BackendConfig {
+		cmdArgs = append(cmdArgs, fmt.Sprintf("-backend-config=%s=%s", pair.Key, pair.Value))
+	}
+	if config.Init.Upgrade {
+		cmdArgs = append(cmdArgs, "-upgrade")
+	}
+	if config.Init.Reconfigure {
+		cmdArgs = append(cmdArgs, "-reconfigure")
+	}
+	cmdArgs = append(cmdArgs, config.Init.Args...)
+	r := runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}, cmdArgs...)
+	return runner.RedactFlagValues(r, "-backend-config=")
+}
+
+// CurrentWorkspace returns the name of the selected terraform workspace.
+func CurrentWorkspace(config model.BinaryConfig) (string, error) {
+	data, err := runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}.WithSuppressErrOutput(true), "workspace", "show").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errShowWorkspaceFailure, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ListWorkspaces returns the names of the terraform workspaces.
+func ListWorkspaces(config model.BinaryConfig) ([]string, error) {
+	data, err := runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}.WithSuppressErrOutput(true), "workspace", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errListWorkspaceFailure, err)
+	}
+	return parseWorkspaces(data), nil
+}
+
+// parseWorkspaces parses the output of terraform workspace list, the selected workspace is marked with a *.
+func parseWorkspaces(data []byte) []string {
+	workspaces := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if name != "" {
+			workspaces = append(workspaces, name)
+		}
 	}
+	return workspaces
 }
@@ -0,0 +1,207 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/controller"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"testing"
+)
+
+func TestParseWorkspaces(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name:     "empty",
+			data:     "",
+			expected: []string{},
+		},
+		{
+			name:     "default_selected",
+			data:     "* default\n  prod\n  prod-east\n\n",
+			expected: []string{"default", "prod", "prod-east"},
+		},
+		{
+			name:     "other_selected",
+			data:     "  default\n* prod\n",
+			expected: []string{"default", "prod"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseWorkspaces([]byte(test.data)))
+		})
+	}
+}
+
+func TestNewWorkspaceRunner(t *testing.T) {
+	tests := []struct {
+		name            string
+		capabilities    Capabilities
+		expectedCommand string
+	}{
+		{name: "unknown", expectedCommand: "terraform workspace new blue"},
+		{name: "old", capabilities: Capabilities{Product: Terraform}, expectedCommand: "terraform workspace new blue"},
+		{name: "select_or_create", capabilities: Capabilities{Product: Terraform, SelectOrCreate: true}, expectedCommand: "terraform workspace select -or-create=true blue"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selecter := BuildSelectWorkspaceRunner(model.BinaryConfig{}, test.capabilities).(*tSelectWorkspace)
+			assert.Equal(t, test.expectedCommand, selecter.newWorkspaceRunner("blue").String())
+		})
+	}
+}
+
+func TestInitRunner(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          model.InitConfig
+		expectedCommand string
+	}{
+		{name: "bare", expectedCommand: "terraform init"},
+		{
+			name: "full",
+			config: model.InitConfig{
+				BackendConfigFiles: []string{"prod.s3.tfbackend"},
+				BackendConfig:      []model.ValuePair{{Key: "bucket", Value: "prod-state"}, {Key: "region", Value: "us-east-1"}},
+				Upgrade:            true,
+				Reconfigure:        true,
+				Args:               []string{"-lockfile=readonly"},
+			},
+			expectedCommand: "terraform init -backend-config=prod.s3.tfbackend -backend-config=bucket=xxxx -backend-config=region=xxxx -upgrade -reconfigure -lockfile=readonly",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedCommand, buildInitRunner(model.BinaryConfig{Init: test.config}).String())
+		})
+	}
+}
+
+func TestSelectWorkspaceInitOnce(t *testing.T) {
+	assert := assert.New(t)
+	init := &countingRunnable{}
+	selecter := &tSelectWorkspace{initRunner: &cachedRunnable{Runnable: init}}
+	assert.NoError(selecter.SelectWorkspace(""))
+	assert.NoError(selecter.SelectWorkspace(""))
+	assert.Equal(1, init.calls)
+}
+
+func TestWorkspaceManager(t *testing.T) {
+	const list = "* default\n  prod-east\n  staging\n"
+	tests := []struct {
+		name          string
+		operation     func(manager *tSelectWorkspace) error
+		create        bool
+		expectedCalls []string
+		expectedErr   error
+	}{
+		{
+			name:          "select_current",
+			operation:     func(m *tSelectWorkspace) error { return m.SelectWorkspace("default") },
+			expectedCalls: []string{"init", "show"},
+		},
+		{
+			name:          "select_existing",
+			operation:     func(m *tSelectWorkspace) error { return m.SelectWorkspace("staging") },
+			expectedCalls: []string{"init", "show", "list", "select staging"},
+		},
+		{
+			name:          "select_prefix_creates",
+			operation:     func(m *tSelectWorkspace) error { return m.SelectWorkspace("prod") },
+			create:        true,
+			expectedCalls: []string{"init", "show", "list", "new prod"},
+		},
+		{
+			name:          "select_missing_without_create",
+			operation:     func(m *tSelectWorkspace) error { return m.SelectWorkspace("prod") },
+			expectedCalls: []string{"init", "show", "list"},
+			expectedErr:   controller.ErrWorkspaceNotFound,
+		},
+		{
+			name:          "new",
+			operation:     func(m *tSelectWorkspace) error { return m.NewWorkspace("prod") },
+			expectedCalls: []string{"init", "list", "new prod"},
+		},
+		{
+			name:          "new_existing",
+			operation:     func(m *tSelectWorkspace) error { return m.NewWorkspace("staging") },
+			expectedCalls: []string{"init", "list"},
+			expectedErr:   errWorkspaceExists,
+		},
+		{
+			name:          "new_empty",
+			operation:     func(m *tSelectWorkspace) error { return m.NewWorkspace("") },
+			expectedCalls: []string{},
+			expectedErr:   errEmptyWorkspace,
+		},
+		{
+			name:          "delete",
+			operation:     func(m *tSelectWorkspace) error { return m.DeleteWorkspace("prod-east", false) },
+			expectedCalls: []string{"init", "list", "delete prod-east false"},
+		},
+		{
+			name:          "delete_force",
+			operation:     func(m *tSelectWorkspace) error { return m.DeleteWorkspace("prod-east", true) },
+			expectedCalls: []string{"init", "list", "delete prod-east true"},
+		},
+		{
+			name:          "delete_missing",
+			operation:     func(m *tSelectWorkspace) error { return m.DeleteWorkspace("prod", true) },
+			expectedCalls: []string{"init", "list"},
+			expectedErr:   controller.ErrWorkspaceNotFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			calls := make([]string, 0)
+			record := func(call string, data string) runner.Runnable {
+				return recordingRunnable{call: call, data: data, calls: &calls}
+			}
+			manager := &tSelectWorkspace{
+				initRunner: &cachedRunnable{Runnable: record("init", "")},
+				showRunner: record("show", "default\n"),
+				listRunner: record("list", list),
+				selectWorkspaceRunner: func(workspace string) runner.Runnable {
+					return record("select "+workspace, "")
+				},
+				newWorkspaceRunner: func(workspace string) runner.Runnable {
+					return record("new "+workspace, "")
+				},
+				deleteWorkspaceRunner: func(workspace string, force bool) runner.Runnable {
+					return record(fmt.Sprintf("delete %s %t", workspace, force), "")
+				},
+				create: test.create,
+			}
+			err := test.operation(manager)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr), err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedCalls, calls)
+		})
+	}
+}
+
+// recordingRunnable records its call when it runs.
+type recordingRunnable struct {
+	call  string
+	data  string
+	calls *[]string
+}
+
+func (r recordingRunnable) Output() ([]byte, error) {
+	*r.calls = append(*r.calls, r.call)
+	return []byte(r.data), nil
+}
+
+func (r recordingRunnable) String() string {
+	return r.call
+}
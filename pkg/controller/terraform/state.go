@@ -10,24 +10,48 @@ import (
 	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
 	"github.com/zclconf/go-cty/cty"
+	"os"
+	"sort"
 )
 
 var (
 	errFailedToGetData   = errors.New("failed to pull state")
 	errBuildStateFailure = errors.New("failed to build terraform state")
+	errReadStateFile     = errors.New("failed to read state file")
+	// ErrUnsupportedOutput is returned for a hostnames or version output of the wrong shape.
+	ErrUnsupportedOutput = errors.New("unsupported output")
 )
 
 type tState struct {
 	stateRunner runner.Runnable
+	naming      Naming
 }
 
 func (t *tState) GetCluster() (model.Cluster, error) {
-	c := model.NewCluster()
 	data, err := t.stateRunner.Output()
 	if err != nil {
-		return c, fmt.Errorf("%w: %v", errFailedToGetData, err)
+		return model.NewCluster(), fmt.Errorf("%w: %v", errFailedToGetData, err)
 	}
+	return readCluster(data, t.naming)
+}
+
+// tStateFile reads the cluster from a local state file instead of pulling the state.
+type tStateFile struct {
+	path   string
+	naming Naming
+}
 
+func (t *tStateFile) GetCluster() (model.Cluster, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return model.NewCluster(), fmt.Errorf("%w: %v", errReadStateFile, err)
+	}
+	return readCluster(data, t.naming)
+}
+
+// readCluster builds the cluster from the outputs of the state.
+func readCluster(data []byte, naming Naming) (model.Cluster, error) {
+	c := model.NewCluster()
 	r := bytes.NewReader(data)
 
 	sf, err := statefile.Read(r)
@@ -39,44 +63,159 @@ func (t *tState) GetCluster() (model.Cluster, error) {
 		return c, nil
 	}
 
-	// Get the hostnames for each group
+	outputs := make(map[string]cty.Value)
+	for key, output := range s.RootModule().OutputValues {
+		if output != nil {
+			outputs[key] = output.Value
+		}
+	}
+	return ReadOutputs(outputs, naming)
+}
+
+// ReadOutputs builds the cluster from the hostnames and version outputs of each color, other outputs are ignored.
+// pulumi reads its stack outputs with it, they have the same names and shapes as the terraform outputs.
+func ReadOutputs(outputs map[string]cty.Value, naming Naming) (model.Cluster, error) {
+	c := model.NewCluster()
 	for _, color := range model.ValidColors {
 		var (
 			hosts   []string
+			details []model.Host
 			version semver.Version
 		)
-		hostnamesKey := fmt.Sprintf("%sHostnames", color)
-		if hostnamesElem, ok := s.RootModule().OutputValues[hostnamesKey]; ok && hostnamesElem != nil {
-			hostsSlice := hostnamesElem.Value.AsValueSlice()
-			hosts = make([]string, len(hostsSlice))
-			for i, val := range hostsSlice {
-				if !val.IsNull() {
-					if val.Type() == cty.String {
-						// TODO: if not a string, return an error.
-						hosts[i] = val.AsString()
-					}
-				}
+		hostnamesKey := naming.HostnamesOutput(color)
+		if value, ok := outputs[hostnamesKey]; ok {
+			var (
+				detailed bool
+				err      error
+			)
+			details, detailed, err = readHosts(value)
+			if err != nil {
+				return c, fmt.Errorf("%w: output %s", err, hostnamesKey)
+			}
+			hosts = make([]string, len(details))
+			for i, host := range details {
+				hosts[i] = host.FQDN
+			}
+			if !detailed {
+				details = nil
 			}
 		}
 
-		versionKey := fmt.Sprintf("%sVersion", color)
-		if versionElem, ok := s.RootModule().OutputValues[versionKey]; ok && versionElem != nil {
-			version, err = semver.Parse(versionElem.Value.AsString())
+		versionKey := naming.VersionOutput(color)
+		if value, ok := outputs[versionKey]; ok && !value.IsNull() {
+			if value.Type() != cty.String {
+				return c, fmt.Errorf("%w: output %s is a %s", ErrUnsupportedOutput, versionKey, value.Type().FriendlyName())
+			}
+			var err error
+			version, err = semver.Parse(value.AsString())
 			if err != nil {
-				return c, fmt.Errorf("%w: %s %s", err, color, versionElem.Value.AsString())
+				return c, fmt.Errorf("%w: %s %s", err, color, value.AsString())
 			}
 		}
 		c[color] = model.ClusterGroup{
 			Hosts:   hosts,
 			Version: version,
+			Details: details,
 		}
 	}
 	return c, nil
 }
 
+// readHosts reads a hostnames output. The output is a list or a map of hostnames, e.g. ["a.example.com"],
+// or of objects with the fqdn, ip, zone and address of each host, e.g. {a = {fqdn = "a.example.com", ip = "10.0.0.1"}}.
+// The address is the terraform resource address or the pulumi urn of the host.
+// The hosts of a map are sorted by key. detailed is true if the hosts have more than a hostname.
+func readHosts(value cty.Value) (hosts []model.Host, detailed bool, err error) {
+	hosts = make([]model.Host, 0)
+	if value.IsNull() {
+		return hosts, false, nil
+	}
+	ty := value.Type()
+	switch {
+	case ty.IsListType() || ty.IsTupleType() || ty.IsSetType():
+		for i, elem := range value.AsValueSlice() {
+			host, objectHost, err := readHost(elem)
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: element %d", err, i)
+			}
+			detailed = detailed || objectHost
+			hosts = append(hosts, host)
+		}
+	case ty.IsMapType() || ty.IsObjectType():
+		elems := value.AsValueMap()
+		keys := make([]string, 0, len(elems))
+		for key := range elems {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			host, _, err := readHost(elems[key])
+			if err != nil {
+				return nil, false, fmt.Errorf("%w: key %s", err, key)
+			}
+			host.Key = key
+			hosts = append(hosts, host)
+		}
+		detailed = true
+	default:
+		return nil, false, fmt.Errorf("%w: %s, expected a list or map", ErrUnsupportedOutput, ty.FriendlyName())
+	}
+	return hosts, detailed, nil
+}
+
+// readHost reads a hostname or an object with the fqdn, ip, zone and address of a host.
+// object is true if the host was an object.
+func readHost(value cty.Value) (host model.Host, object bool, err error) {
+	if value.IsNull() {
+		return host, false, nil
+	}
+	ty := value.Type()
+	if ty == cty.String {
+		return model.Host{FQDN: value.AsString()}, false, nil
+	}
+	if !ty.IsObjectType() && !ty.IsMapType() {
+		return host, false, fmt.Errorf("%w: host is a %s, expected a string or object", ErrUnsupportedOutput, ty.FriendlyName())
+	}
+	attributes := value.AsValueMap()
+	fields := []struct {
+		name     string
+		value    *string
+		required bool
+	}{
+		{name: "fqdn", value: &host.FQDN, required: true},
+		{name: "ip", value: &host.IP},
+		{name: "zone", value: &host.Zone},
+		{name: "address", value: &host.Address},
+	}
+	for _, field := range fields {
+		attribute, ok := attributes[field.name]
+		if !ok || attribute.IsNull() {
+			if field.required {
+				return host, true, fmt.Errorf("%w: host has no %s", ErrUnsupportedOutput, field.name)
+			}
+			continue
+		}
+		if attribute.Type() != cty.String {
+			return host, true, fmt.Errorf("%w: %s is a %s, expected a string", ErrUnsupportedOutput, field.name, attribute.Type().FriendlyName())
+		}
+		*field.value = attribute.AsString()
+	}
+	return host, true, nil
+}
+
 // BuildStateDeterminer builds a terraform specific ClusterGetter.
-func BuildStateDeterminer(config model.BinaryConfig) controller.ClusterGetter {
+func BuildStateDeterminer(config model.BinaryConfig, naming Naming) controller.ClusterGetter {
 	return &tState{
 		stateRunner: runner.NewCMDRunner(config.WorkingDirectory, config.Binary, runner.Options{}.WithSuppressErrOutput(true), "state", "pull"),
+		naming:      naming,
+	}
+}
+
+// BuildStateFileDeterminer builds a ClusterGetter reading a local state file, e.g. terraform.tfstate or the output of
+// terraform state pull. The binary is never run.
+func BuildStateFileDeterminer(path string, naming Naming) controller.ClusterGetter {
+	return &tStateFile{
+		path:   path,
+		naming: naming,
 	}
 }
@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -76,3 +78,163 @@ func TestGetCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClusterFromStateFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name            string
+		data            string
+		missing         bool
+		expectedCluster model.Cluster
+		expectedErr     error
+	}{
+		{
+			name:            "missing_file",
+			missing:         true,
+			expectedCluster: emptyCluster,
+			expectedErr:     errReadStateFile,
+		},
+		{
+			name:            "invalid_state",
+			data:            `{}`,
+			expectedCluster: emptyCluster,
+			expectedErr:     errBuildStateFailure,
+		},
+		{
+			name:            "empty_state",
+			data:            emptyState,
+			expectedCluster: emptyCluster,
+		},
+		{
+			name: "clean_state",
+			data: cleanState,
+			expectedCluster: model.Cluster{
+				model.Blue: model.ClusterGroup{
+					Hosts:   []string{},
+					Version: semver.MustParse("0.10.0"),
+				},
+				model.Green: model.ClusterGroup{
+					Hosts:   []string{"carousel-demo-ffdbb6.example.com", "carousel-demo-ea9412.example.com"},
+					Version: semver.MustParse("0.10.0"),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			path := filepath.Join(dir, test.name+".tfstate")
+			if !test.missing {
+				assert.NoError(os.WriteFile(path, []byte(test.data), 0644))
+			}
+			cluster, err := BuildStateFileDeterminer(path, Naming{}).GetCluster()
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedCluster, cluster)
+		})
+	}
+}
+
+func TestGetClusterHostOutputs(t *testing.T) {
+	const version = `"blueVersion": {"value": "0.10.0", "type": "string"}`
+	tests := []struct {
+		name          string
+		outputs       string
+		expectedGroup model.ClusterGroup
+		expectedErr   error
+	}{
+		{
+			name:    "list_of_objects",
+			outputs: `"blueHostnames": {"value": [{"fqdn": "a.example.com", "ip": "10.0.0.1", "zone": "us-east-1a", "address": "aws_instance.server[0]"}, {"fqdn": "b.example.com", "ip": null}], "type": ["tuple", [["object", {"fqdn": "string", "ip": "string", "zone": "string", "address": "string"}], ["object", {"fqdn": "string", "ip": "string"}]]]}, ` + version,
+			expectedGroup: model.ClusterGroup{
+				Hosts:   []string{"a.example.com", "b.example.com"},
+				Version: semver.MustParse("0.10.0"),
+				Details: []model.Host{
+					{FQDN: "a.example.com", IP: "10.0.0.1", Zone: "us-east-1a", Address: "aws_instance.server[0]"},
+					{FQDN: "b.example.com"},
+				},
+			},
+		},
+		{
+			name:    "map_of_objects",
+			outputs: `"blueHostnames": {"value": {"z": {"fqdn": "z.example.com", "zone": "b"}, "a": {"fqdn": "a.example.com", "zone": "a"}}, "type": ["map", ["object", {"fqdn": "string", "zone": "string"}]]}, ` + version,
+			expectedGroup: model.ClusterGroup{
+				Hosts:   []string{"a.example.com", "z.example.com"},
+				Version: semver.MustParse("0.10.0"),
+				Details: []model.Host{
+					{FQDN: "a.example.com", Zone: "a", Key: "a"},
+					{FQDN: "z.example.com", Zone: "b", Key: "z"},
+				},
+			},
+		},
+		{
+			name:    "map_of_hostnames",
+			outputs: `"blueHostnames": {"value": {"web-1": "b.example.com", "web-0": "a.example.com"}, "type": ["map", "string"]}, ` + version,
+			expectedGroup: model.ClusterGroup{
+				Hosts:   []string{"a.example.com", "b.example.com"},
+				Version: semver.MustParse("0.10.0"),
+				Details: []model.Host{
+					{FQDN: "a.example.com", Key: "web-0"},
+					{FQDN: "b.example.com", Key: "web-1"},
+				},
+			},
+		},
+		{
+			name:    "list_of_hostnames",
+			outputs: `"blueHostnames": {"value": ["a.example.com", null], "type": ["list", "string"]}, ` + version,
+			expectedGroup: model.ClusterGroup{
+				Hosts:   []string{"a.example.com", ""},
+				Version: semver.MustParse("0.10.0"),
+			},
+		},
+		{
+			name:    "null_output",
+			outputs: `"blueHostnames": {"value": null, "type": ["list", "string"]}, ` + version,
+			expectedGroup: model.ClusterGroup{
+				Hosts:   []string{},
+				Version: semver.MustParse("0.10.0"),
+			},
+		},
+		{
+			name:        "string_output",
+			outputs:     `"blueHostnames": {"value": "a.example.com", "type": "string"}, ` + version,
+			expectedErr: ErrUnsupportedOutput,
+		},
+		{
+			name:        "list_of_numbers",
+			outputs:     `"blueHostnames": {"value": [1, 2], "type": ["list", "number"]}, ` + version,
+			expectedErr: ErrUnsupportedOutput,
+		},
+		{
+			name:        "object_without_fqdn",
+			outputs:     `"blueHostnames": {"value": [{"ip": "10.0.0.1"}], "type": ["tuple", [["object", {"ip": "string"}]]]}, ` + version,
+			expectedErr: ErrUnsupportedOutput,
+		},
+		{
+			name:        "fqdn_not_a_string",
+			outputs:     `"blueHostnames": {"value": [{"fqdn": 1}], "type": ["tuple", [["object", {"fqdn": "number"}]]]}, ` + version,
+			expectedErr: ErrUnsupportedOutput,
+		},
+		{
+			name:        "version_not_a_string",
+			outputs:     `"blueVersion": {"value": ["0.10.0"], "type": ["list", "string"]}`,
+			expectedErr: ErrUnsupportedOutput,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			stateGetter := tState{stateRunner: simplerunnable{Name: "testRunner", Data: []byte(outputsState(test.outputs))}}
+			cluster, err := stateGetter.GetCluster()
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedGroup, cluster[model.Blue])
+		})
+	}
+}
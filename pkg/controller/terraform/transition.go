@@ -6,7 +6,6 @@ import (
 	"github.com/xmidt-org/carousel/pkg/controller"
 	"github.com/xmidt-org/carousel/pkg/model"
 	"github.com/xmidt-org/carousel/pkg/runner"
-	"strings"
 )
 
 var (
@@ -18,30 +17,83 @@ type tTransition struct {
 	// applyRunBuilder is a helper function that generates a Runnable for a given Step
 	config           model.BinaryConfig
 	transitionConfig TerraformTransitionConfig
+	naming           Naming
+	// getter reads the cluster before each step to check the plan in SafeMode.
+	getter controller.ClusterGetter
+	// pending are the resources to replace with the next apply, nil if hosts are tainted instead.
+	pending *controller.PendingReplacements
+	// applies is the number of applies created, the state is refreshed on the first one with FirstRefresh.
+	applies int
+	// previous is the step of the last apply created, the next step only targets the Color Groups changed from it.
+	previous model.Step
 }
 
 func (t *tTransition) CreateApply(target model.ClusterState, step model.Step) runner.Runnable {
+	tuningArgs := t.tuningArgs(target, step, t.applies == 0)
+	t.applies++
+	t.previous = step
+	if t.pending != nil {
+		return controller.NewReplaceApply(t.pending, replaceFlag, func(replaceArgs []string) runner.Runnable {
+			return t.buildApply(target, step, append(append([]string{}, replaceArgs...), tuningArgs...))
+		})
+	}
+	return t.buildApply(target, step, tuningArgs)
+}
+
+func (t *tTransition) buildApply(target model.ClusterState, step model.Step, extraArgs []string) runner.Runnable {
+	runConfig := runner.Options{
+		ShowOutput:        t.transitionConfig.AttachStdOut,
+		SuppressErrOutput: !t.transitionConfig.AttachStdErr,
+	}
+	if t.transitionConfig.SafeMode {
+		return &planApply{
+			getter: t.getter,
+			naming: t.naming,
+			step:   step,
+			plan: func(planFile string) runner.Runnable {
+				cmdArgs := append([]string{"plan", "-out=" + planFile}, extraArgs...)
+				return t.buildRunner(runConfig, append(cmdArgs, t.stepArgs(target, step)...)...)
+			},
+			show: func(planFile string) runner.Runnable {
+				return t.buildRunner(runner.Options{}, "show", "-json", planFile)
+			},
+			apply: func(planFile string) runner.Runnable {
+				// the variables, refresh and targets are part of the plan file.
+				cmdArgs := append([]string{"apply"}, t.transitionConfig.Tuning.lockArgs()...)
+				return t.buildRunner(runConfig, append(cmdArgs, planFile)...)
+			},
+		}
+	}
+
 	cmdArgs := []string{
 		"apply", "--auto-approve",
 	}
+	cmdArgs = append(cmdArgs, extraArgs...)
+	return t.buildRunner(runConfig, append(cmdArgs, t.stepArgs(target, step)...)...)
+}
 
+// stepArgs returns the variables of the step.
+func (t *tTransition) stepArgs(target model.ClusterState, step model.Step) []string {
+	cmdArgs := make([]string, 0)
+	for _, file := range t.config.VarFiles {
+		cmdArgs = append(cmdArgs, "-var-file="+file)
+	}
 	for _, color := range model.ValidColors {
 		cmdArgs = append(cmdArgs,
-			"-var", fmt.Sprintf("version%sCount=%d", strings.Title(color.String()), step[color]),
+			"-var", fmt.Sprintf("%s=%d", t.naming.CountVariable(color), step[color]),
 		)
 		cmdArgs = append(cmdArgs,
-			"-var", fmt.Sprintf("version%s=%s", strings.Title(color.String()), target[color].Version.String()),
+			"-var", fmt.Sprintf("%s=%s", t.naming.VersionVariable(color), target[color].Version.String()),
 		)
 	}
 
 	for _, elem := range t.transitionConfig.Args {
 		cmdArgs = append(cmdArgs, "-var", fmt.Sprintf("%s=%s", elem.Key, elem.Value))
 	}
+	return cmdArgs
+}
 
-	runConfig := runner.Options{
-		ShowOutput:        t.transitionConfig.AttachStdOut,
-		SuppressErrOutput: !t.transitionConfig.AttachStdErr,
-	}
+func (t *tTransition) buildRunner(runConfig runner.Options, cmdArgs ...string) runner.Runnable {
 	r := runner.NewCMDRunner(t.config.WorkingDirectory, t.config.Binary, runConfig, cmdArgs...)
 	r = runner.AddEnvironment(r, "TF_VAR_", t.config.PrivateArgs)
 	r = runner.AddEnvironment(r, "", t.config.Environment)
@@ -53,12 +105,24 @@ type TerraformTransitionConfig struct {
 	Args         []model.ValuePair
 	AttachStdOut bool
 	AttachStdErr bool
+	// HostReplacement is how the hosts failing validation are replaced.
+	// AutoReplacement is resolved when the controller is built, the zero value is TaintReplacement.
+	HostReplacement HostReplacement
+	// SafeMode plans each step and checks the plan before applying the plan file.
+	// Only the resources of the Color Groups changing count can be created or destroyed.
+	SafeMode bool
+	// Tuning tunes the apply of each step.
+	Tuning StepTuning
+	// Capabilities are what the binary supports, the zero value is an unknown binary.
+	Capabilities Capabilities
 }
 
 // BuildTransitioner builds a terraform specific controller.ApplyBuilder.
-func BuildTransitioner(config model.BinaryConfig, transitionConfig TerraformTransitionConfig) controller.ApplyBuilder {
+func BuildTransitioner(config model.BinaryConfig, transitionConfig TerraformTransitionConfig, naming Naming) controller.ApplyBuilder {
 	return &tTransition{
 		config:           config,
 		transitionConfig: transitionConfig,
+		naming:           naming,
+		getter:           BuildStateDeterminer(config, naming),
 	}
 }
@@ -0,0 +1,25 @@
+package terraform
+
+import (
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+)
+
+func TestVarFilesArgs(t *testing.T) {
+	assert := assert.New(t)
+	transition := &tTransition{
+		config: model.BinaryConfig{
+			VarFiles: []string{"common.tfvars", "prod.tfvars"},
+		},
+		transitionConfig: TerraformTransitionConfig{Args: []model.ValuePair{{Key: "region", Value: "us-east-1"}}},
+	}
+	apply := transition.CreateApply(model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("0.11.0")},
+	}, model.Step{model.Blue: 0, model.Green: 1})
+	// the variables of the step and the args take precedence over the var files.
+	assert.Equal("terraform apply --auto-approve -var-file=common.tfvars -var-file=prod.tfvars"+
+		" -var versionBlueCount=0 -var versionBlue=0.10.0 -var versionGreenCount=1 -var versionGreen=0.11.0 -var region=us-east-1", apply.String())
+}
@@ -0,0 +1,123 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	errUnknownRefresh = errors.New("unknown refresh")
+	errInvalidTuning  = errors.New("invalid step tuning")
+)
+
+// Refresh is when terraform refreshes the state during the apply of a step.
+type Refresh string
+
+const (
+	// AlwaysRefresh refreshes the state on every step, like a plain terraform apply.
+	AlwaysRefresh Refresh = "always"
+	// FirstRefresh refreshes the state on the first step only, the following steps use -refresh=false.
+	FirstRefresh Refresh = "first"
+	// NeverRefresh uses -refresh=false on every step.
+	NeverRefresh Refresh = "never"
+)
+
+// ParseRefresh parses the name of a Refresh, empty is AlwaysRefresh.
+func ParseRefresh(name string) (Refresh, error) {
+	switch refresh := Refresh(strings.ToLower(name)); refresh {
+	case "":
+		return AlwaysRefresh, nil
+	case AlwaysRefresh, FirstRefresh, NeverRefresh:
+		return refresh, nil
+	default:
+		return "", fmt.Errorf("%w: %s, try [always, first, never]", errUnknownRefresh, name)
+	}
+}
+
+// StepTuning tunes the terraform apply of each step, the zero value is a plain terraform apply.
+type StepTuning struct {
+	// Parallelism limits the concurrent operations of terraform with -parallelism.
+	// 0 uses the terraform default.
+	Parallelism int
+	// Refresh is when the state is refreshed, the zero value is AlwaysRefresh.
+	Refresh Refresh
+	// LockTimeout is how long terraform waits for the state lock with -lock-timeout, e.g. 5m.
+	// Empty uses the terraform default.
+	LockTimeout string
+	// TargetGroups scopes the apply to the modules of the Color Groups the step changes with -target.
+	TargetGroups bool
+}
+
+// Validate checks the Parallelism and LockTimeout.
+func (s StepTuning) Validate() error {
+	if s.Parallelism < 0 {
+		return fmt.Errorf("%w: parallelism %d can't be negative", errInvalidTuning, s.Parallelism)
+	}
+	if s.LockTimeout != "" {
+		if _, err := time.ParseDuration(s.LockTimeout); err != nil {
+			return fmt.Errorf("%w: lock timeout %v", errInvalidTuning, err)
+		}
+	}
+	return nil
+}
+
+// lockArgs returns the arguments also accepted when applying a plan file.
+func (s StepTuning) lockArgs() []string {
+	args := make([]string, 0)
+	if s.Parallelism > 0 {
+		args = append(args, "-parallelism="+strconv.Itoa(s.Parallelism))
+	}
+	if s.LockTimeout != "" {
+		args = append(args, "-lock-timeout="+s.LockTimeout)
+	}
+	return args
+}
+
+// tuningArgs returns the arguments of the StepTuning for the plan or apply of a step.
+// first is true for the first step created by the tTransition.
+func (t *tTransition) tuningArgs(target model.ClusterState, step model.Step, first bool) []string {
+	tuning := t.transitionConfig.Tuning
+	args := tuning.lockArgs()
+	if tuning.Refresh == NeverRefresh || (tuning.Refresh == FirstRefresh && !first) {
+		args = append(args, "-refresh=false")
+	}
+	if tuning.TargetGroups {
+		for _, color := range t.changedGroups(target, step) {
+			args = append(args, "-target=module."+t.naming.Module(color))
+		}
+	}
+	return args
+}
+
+// changedGroups returns the Color Groups the step changes the count of, compared to the previous step.
+// The first step is compared to the current cluster, so a version change is also targeted. If the cluster can't
+// be read no Color Group is returned, so the apply isn't targeted.
+func (t *tTransition) changedGroups(target model.ClusterState, step model.Step) []model.Color {
+	colors := make([]model.Color, 0)
+	if t.previous != nil {
+		for _, color := range model.ValidColors {
+			if step[color] != t.previous[color] {
+				colors = append(colors, color)
+			}
+		}
+		return colors
+	}
+	if t.getter == nil {
+		return nil
+	}
+	cluster, err := t.getter.GetCluster()
+	if err != nil {
+		return nil
+	}
+	for _, color := range model.ValidColors {
+		current := cluster[color]
+		if step[color] != len(current.Hosts) || (step[color] > 0 && !target[color].Version.Equals(current.Version)) {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
@@ -0,0 +1,186 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"strings"
+	"testing"
+)
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		name            string
+		expectedRefresh Refresh
+		expectedErr     error
+	}{
+		{name: "", expectedRefresh: AlwaysRefresh},
+		{name: "always", expectedRefresh: AlwaysRefresh},
+		{name: "First", expectedRefresh: FirstRefresh},
+		{name: "never", expectedRefresh: NeverRefresh},
+		{name: "sometimes", expectedErr: errUnknownRefresh},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			refresh, err := ParseRefresh(test.name)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedRefresh, refresh)
+		})
+	}
+}
+
+func TestStepTuningValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		tuning      StepTuning
+		expectedErr error
+	}{
+		{name: "zero"},
+		{name: "valid", tuning: StepTuning{Parallelism: 20, LockTimeout: "5m"}},
+		{name: "negative_parallelism", tuning: StepTuning{Parallelism: -1}, expectedErr: errInvalidTuning},
+		{name: "bad_lock_timeout", tuning: StepTuning{LockTimeout: "5 minutes"}, expectedErr: errInvalidTuning},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.tuning.Validate()
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestStepTuningArgs(t *testing.T) {
+	// cleanState has no blue hosts and 2 green hosts of 0.10.0.
+	oldVersion := model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+	}
+	newVersion := model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.11.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+	}
+	tests := []struct {
+		name         string
+		tuning       StepTuning
+		safeMode     bool
+		target       model.ClusterState
+		steps        []model.Step
+		state        []byte
+		expectedArgs []string
+	}{
+		{
+			name:         "none",
+			target:       newVersion,
+			steps:        []model.Step{{model.Blue: 1, model.Green: 2}},
+			expectedArgs: []string{"terraform apply --auto-approve -var"},
+		},
+		{
+			name:   "refresh_first",
+			tuning: StepTuning{Refresh: FirstRefresh, Parallelism: 5, LockTimeout: "1m"},
+			target: newVersion,
+			steps:  []model.Step{{model.Blue: 1, model.Green: 2}, {model.Blue: 1, model.Green: 1}},
+			expectedArgs: []string{
+				"terraform apply --auto-approve -parallelism=5 -lock-timeout=1m -var",
+				"terraform apply --auto-approve -parallelism=5 -lock-timeout=1m -refresh=false -var",
+			},
+		},
+		{
+			name:   "refresh_never",
+			tuning: StepTuning{Refresh: NeverRefresh},
+			target: newVersion,
+			steps:  []model.Step{{model.Blue: 1, model.Green: 2}},
+			expectedArgs: []string{
+				"terraform apply --auto-approve -refresh=false -var",
+			},
+		},
+		{
+			name:   "target_groups",
+			tuning: StepTuning{TargetGroups: true},
+			target: newVersion,
+			steps:  []model.Step{{model.Blue: 1, model.Green: 2}, {model.Blue: 1, model.Green: 1}, {model.Blue: 0, model.Green: 2}},
+			state:  []byte(cleanState),
+			// the steps after the first are compared to the previous step, not to the unchanged state.
+			expectedArgs: []string{
+				"terraform apply --auto-approve -target=module.blue -var",
+				"terraform apply --auto-approve -target=module.green -var",
+				"terraform apply --auto-approve -target=module.blue -target=module.green -var",
+			},
+		},
+		{
+			name:   "target_version_change",
+			tuning: StepTuning{TargetGroups: true},
+			target: model.ClusterState{
+				model.Blue:  model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+				model.Green: model.ClusterGroupState{Version: semver.MustParse("0.11.0")},
+			},
+			steps:        []model.Step{{model.Blue: 0, model.Green: 2}},
+			state:        []byte(cleanState),
+			expectedArgs: []string{"terraform apply --auto-approve -target=module.green -var"},
+		},
+		{
+			name:         "target_unknown_cluster",
+			tuning:       StepTuning{TargetGroups: true},
+			target:       oldVersion,
+			steps:        []model.Step{{model.Blue: 1, model.Green: 2}},
+			expectedArgs: []string{"terraform apply --auto-approve -var"},
+		},
+		{
+			name:     "safe_mode",
+			tuning:   StepTuning{Refresh: NeverRefresh, Parallelism: 5, TargetGroups: true},
+			safeMode: true,
+			target:   newVersion,
+			steps:    []model.Step{{model.Blue: 1, model.Green: 2}},
+			state:    []byte(cleanState),
+			expectedArgs: []string{
+				"terraform plan -out=<plan> -parallelism=5 -refresh=false -target=module.blue -var",
+				"terraform apply -parallelism=5 <plan>",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			transition := &tTransition{
+				transitionConfig: TerraformTransitionConfig{Tuning: test.tuning, SafeMode: test.safeMode},
+				getter:           &tState{stateRunner: simplerunnable{Name: "state", Data: test.state}},
+			}
+			commands := make([]string, 0)
+			for _, step := range test.steps {
+				commands = append(commands, transition.CreateApply(test.target, step).String())
+			}
+			for i, expected := range test.expectedArgs {
+				if test.safeMode {
+					assert.True(strings.Contains(commands[0], expected), commands[0])
+					continue
+				}
+				assert.True(strings.HasPrefix(commands[i], expected), commands[i])
+			}
+		})
+	}
+}
+
+func TestTargetGroupsReadsStateOnce(t *testing.T) {
+	assert := assert.New(t)
+	getter := &countingGetter{}
+	transition := &tTransition{
+		transitionConfig: TerraformTransitionConfig{Tuning: StepTuning{TargetGroups: true}},
+		getter:           getter,
+	}
+	target := model.ClusterState{
+		model.Blue:  model.ClusterGroupState{Count: 2, Version: semver.MustParse("0.11.0")},
+		model.Green: model.ClusterGroupState{Version: semver.MustParse("0.10.0")},
+	}
+	for _, step := range []model.Step{{model.Blue: 0, model.Green: 2}, {model.Blue: 1, model.Green: 2}, {model.Blue: 1, model.Green: 1}} {
+		transition.CreateApply(target, step)
+	}
+	assert.Equal(1, getter.calls)
+}
@@ -0,0 +1,130 @@
+package terraform
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/runner"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	errVersionFailure = errors.New("failed to get terraform version")
+	// ErrUnsupportedVersion is returned for a binary too old for carousel.
+	ErrUnsupportedVersion = errors.New("unsupported binary version")
+)
+
+// versionRegex finds the version in the text output of terraform version, e.g. Terraform v1.5.7.
+var versionRegex = regexp.MustCompile(`v(\d+\.\d+\.\d+\S*)`)
+
+// Product is the infrastructure as code tool behind the binary.
+type Product string
+
+const (
+	Terraform Product = "terraform"
+	OpenTofu  Product = "opentofu"
+)
+
+func (p Product) String() string {
+	if p == OpenTofu {
+		return "OpenTofu"
+	}
+	return "Terraform"
+}
+
+var (
+	// minimumVersion is the first terraform version with the version 4 state carousel reads.
+	minimumVersion = semver.MustParse("0.12.0")
+	// replaceVersion is the first terraform version with apply -replace.
+	replaceVersion = semver.MustParse("0.15.2")
+	// selectOrCreateVersion is the first terraform version with workspace select -or-create.
+	selectOrCreateVersion = semver.MustParse("1.4.0")
+)
+
+// Capabilities are what the binary supports, found from its product and version.
+// The zero value is an unknown binary, which only gets what every supported version does.
+type Capabilities struct {
+	Product Product        `json:"product"`
+	Version semver.Version `json:"version"`
+	// Replace is true if apply supports -replace, otherwise resources are tainted.
+	Replace bool `json:"replace"`
+	// SelectOrCreate is true if workspace select supports -or-create.
+	SelectOrCreate bool `json:"select_or_create"`
+}
+
+func (c Capabilities) String() string {
+	if c.Product == "" {
+		return "unknown binary"
+	}
+	return fmt.Sprintf("%s %s", c.Product, c.Version)
+}
+
+// DetectCapabilities runs version -json, or version for binaries without -json, and returns what the binary supports.
+// Versions older than 0.12.0 fail with ErrUnsupportedVersion.
+func DetectCapabilities(config model.BinaryConfig) (Capabilities, error) {
+	options := runner.Options{}.WithSuppressErrOutput(true)
+	version := func(args ...string) ([]byte, error) {
+		return runner.NewCMDRunner(config.WorkingDirectory, config.Binary, options, append([]string{"version"}, args...)...).Output()
+	}
+	data, err := version("-json")
+	if err != nil || !json.Valid(data) {
+		// version -json is missing from older versions, it may also fail on them.
+		data, err = version()
+		if err != nil {
+			return Capabilities{}, fmt.Errorf("%w: %v", errVersionFailure, err)
+		}
+		return parseCapabilities(data, detectProduct(data, config.Binary))
+	}
+	product := detectProduct(nil, config.Binary)
+	if product != OpenTofu {
+		// OpenTofu prints the same json as terraform, so a renamed or wrapped binary is only found from its text output.
+		if text, err := version(); err == nil {
+			product = detectProduct(text, config.Binary)
+		}
+	}
+	return parseCapabilities(data, product)
+}
+
+// detectProduct finds OpenTofu from the text output of version or a binary named tofu, everything else is terraform.
+func detectProduct(text []byte, binary string) Product {
+	if strings.HasPrefix(strings.TrimSpace(string(text)), "OpenTofu") || strings.HasPrefix(filepath.Base(binary), "tofu") {
+		return OpenTofu
+	}
+	return Terraform
+}
+
+// parseCapabilities parses the output of version -json, or the text output of versions without -json, for the product.
+func parseCapabilities(data []byte, product Product) (Capabilities, error) {
+	var output struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	text := string(data)
+	if err := json.Unmarshal(data, &output); err == nil && output.TerraformVersion != "" {
+		text = output.TerraformVersion
+	} else if matches := versionRegex.FindStringSubmatch(text); matches != nil {
+		text = matches[1]
+	}
+	version, err := semver.Parse(text)
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("%w: %v", errVersionFailure, err)
+	}
+
+	capabilities := Capabilities{Product: product, Version: version}
+	switch product {
+	case OpenTofu:
+		// every OpenTofu version is a fork of terraform 1.6.
+		capabilities.Replace = true
+		capabilities.SelectOrCreate = true
+	default:
+		if version.LT(minimumVersion) {
+			return capabilities, fmt.Errorf("%w: %s, carousel needs terraform %s or newer", ErrUnsupportedVersion, capabilities, minimumVersion)
+		}
+		capabilities.Replace = version.GE(replaceVersion)
+		capabilities.SelectOrCreate = version.GE(selectOrCreateVersion)
+	}
+	return capabilities, nil
+}
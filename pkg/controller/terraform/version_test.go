@@ -0,0 +1,147 @@
+package terraform
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	tests := []struct {
+		name                 string
+		data                 string
+		product              Product
+		expectedCapabilities Capabilities
+		expectedErr          error
+	}{
+		{
+			name:                 "json",
+			data:                 `{"terraform_version":"1.5.7","platform":"linux_amd64","provider_selections":{},"terraform_outdated":false}`,
+			expectedCapabilities: Capabilities{Product: Terraform, Version: semver.MustParse("1.5.7"), Replace: true, SelectOrCreate: true},
+		},
+		{
+			name:                 "text",
+			data:                 "Terraform v0.14.10\n\nYour version of Terraform is out of date!",
+			expectedCapabilities: Capabilities{Product: Terraform, Version: semver.MustParse("0.14.10")},
+		},
+		{
+			name:                 "replace",
+			data:                 `{"terraform_version":"0.15.2"}`,
+			expectedCapabilities: Capabilities{Product: Terraform, Version: semver.MustParse("0.15.2"), Replace: true},
+		},
+		{
+			name:                 "prerelease",
+			data:                 `{"terraform_version":"1.6.0-beta1"}`,
+			expectedCapabilities: Capabilities{Product: Terraform, Version: semver.MustParse("1.6.0-beta1"), Replace: true, SelectOrCreate: true},
+		},
+		{
+			name:                 "opentofu_text",
+			data:                 "OpenTofu v1.6.2\non linux_amd64",
+			product:              OpenTofu,
+			expectedCapabilities: Capabilities{Product: OpenTofu, Version: semver.MustParse("1.6.2"), Replace: true, SelectOrCreate: true},
+		},
+		{
+			name:                 "opentofu_json",
+			data:                 `{"terraform_version":"1.7.0"}`,
+			product:              OpenTofu,
+			expectedCapabilities: Capabilities{Product: OpenTofu, Version: semver.MustParse("1.7.0"), Replace: true, SelectOrCreate: true},
+		},
+		{
+			name:        "unsupported",
+			data:        "Terraform v0.11.14",
+			expectedErr: ErrUnsupportedVersion,
+		},
+		{
+			name:        "unknown",
+			data:        "something else",
+			expectedErr: errVersionFailure,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			product := test.product
+			if product == "" {
+				product = Terraform
+			}
+			capabilities, err := parseCapabilities([]byte(test.data), product)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expectedCapabilities, capabilities)
+		})
+	}
+}
+
+func TestDetectProduct(t *testing.T) {
+	tests := []struct {
+		name            string
+		text            string
+		binary          string
+		expectedProduct Product
+	}{
+		{name: "terraform", text: "Terraform v1.5.7\non linux_amd64", binary: "terraform", expectedProduct: Terraform},
+		{name: "opentofu_text", text: "OpenTofu v1.6.2\non linux_amd64", binary: "/opt/bin/iac", expectedProduct: OpenTofu},
+		{name: "opentofu_binary", binary: "/usr/local/bin/tofu", expectedProduct: OpenTofu},
+		{name: "unknown", text: "something else", expectedProduct: Terraform},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedProduct, detectProduct([]byte(test.text), test.binary))
+		})
+	}
+}
+
+func TestDetectCapabilitiesWrappedOpenTofu(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "iac")
+	script := `#!/bin/sh
+if [ "$2" = "-json" ]; then
+  echo '{"terraform_version":"1.6.2"}'
+else
+  echo 'OpenTofu v1.6.2'
+fi
+`
+	assert.NoError(os.WriteFile(binary, []byte(script), 0700))
+	capabilities, err := DetectCapabilities(model.BinaryConfig{Binary: binary, WorkingDirectory: dir})
+	assert.NoError(err)
+	assert.Equal(Capabilities{Product: OpenTofu, Version: semver.MustParse("1.6.2"), Replace: true, SelectOrCreate: true}, capabilities)
+}
+
+func TestResolveHostReplacement(t *testing.T) {
+	newTerraform := Capabilities{Product: Terraform, Version: semver.MustParse("1.5.7"), Replace: true}
+	oldTerraform := Capabilities{Product: Terraform, Version: semver.MustParse("0.14.10")}
+	tests := []struct {
+		name                string
+		capabilities        Capabilities
+		replacement         HostReplacement
+		expectedReplacement HostReplacement
+		expectedErr         error
+	}{
+		{name: "auto_new", capabilities: newTerraform, replacement: AutoReplacement, expectedReplacement: ApplyReplacement},
+		{name: "auto_old", capabilities: oldTerraform, replacement: AutoReplacement, expectedReplacement: TaintReplacement},
+		{name: "auto_unknown", replacement: AutoReplacement, expectedReplacement: TaintReplacement},
+		{name: "taint_new", capabilities: newTerraform, replacement: TaintReplacement, expectedReplacement: TaintReplacement},
+		{name: "replace_unknown", replacement: ApplyReplacement, expectedReplacement: ApplyReplacement},
+		{name: "replace_old", capabilities: oldTerraform, replacement: ApplyReplacement, expectedReplacement: TaintReplacement, expectedErr: ErrUnsupportedVersion},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			replacement, err := ResolveHostReplacement(test.capabilities, test.replacement)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedReplacement, replacement)
+		})
+	}
+}
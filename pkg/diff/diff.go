@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"sort"
+
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+// GroupDiff is the change of a single Color Group.
+type GroupDiff struct {
+	Color        model.Color    `json:"group"`
+	FromCount    int            `json:"from_count"`
+	ToCount      int            `json:"to_count"`
+	FromVersion  semver.Version `json:"from_version"`
+	ToVersion    semver.Version `json:"to_version"`
+	AddedHosts   []string       `json:"added_hosts,omitempty"`
+	RemovedHosts []string       `json:"removed_hosts,omitempty"`
+}
+
+// CountChanged returns true if the number of nodes changed.
+func (g GroupDiff) CountChanged() bool {
+	return g.FromCount != g.ToCount
+}
+
+// VersionChanged returns true if the group has nodes before and after with different versions.
+// The version of an empty group has no meaning, so it is ignored.
+func (g GroupDiff) VersionChanged() bool {
+	if g.FromCount == 0 || g.ToCount == 0 {
+		return false
+	}
+	return !g.FromVersion.EQ(g.ToVersion)
+}
+
+// Changed returns true if the count, version or hosts changed.
+func (g GroupDiff) Changed() bool {
+	return g.CountChanged() || g.VersionChanged() || len(g.AddedHosts) > 0 || len(g.RemovedHosts) > 0
+}
+
+// Diff is the change of each Color Group, in the order of model.ValidColors.
+type Diff []GroupDiff
+
+// Changed returns true if any Color Group changed.
+func (d Diff) Changed() bool {
+	for _, group := range d {
+		if group.Changed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes returns only the Color Groups that changed.
+func (d Diff) Changes() Diff {
+	changes := Diff{}
+	for _, group := range d {
+		if group.Changed() {
+			changes = append(changes, group)
+		}
+	}
+	return changes
+}
+
+// Clusters returns the hosts added and removed, and the count and version changes going from one Cluster to another.
+func Clusters(from model.Cluster, to model.Cluster) Diff {
+	d := States(from.AsClusterState(), to.AsClusterState())
+	for i, group := range d {
+		d[i].AddedHosts = missing(to[group.Color].Hosts, from[group.Color].Hosts)
+		d[i].RemovedHosts = missing(from[group.Color].Hosts, to[group.Color].Hosts)
+	}
+	return d
+}
+
+// States returns the count and version changes going from one ClusterState to another.
+func States(from model.ClusterState, to model.ClusterState) Diff {
+	d := make(Diff, 0, len(model.ValidColors))
+	for _, color := range model.ValidColors {
+		d = append(d, GroupDiff{
+			Color:       color,
+			FromCount:   from[color].Count,
+			ToCount:     to[color].Count,
+			FromVersion: from[color].Version,
+			ToVersion:   to[color].Version,
+		})
+	}
+	return d
+}
+
+// missing returns the sorted hosts that are not in others.
+func missing(hosts []string, others []string) []string {
+	found := make(map[string]bool, len(others))
+	for _, host := range others {
+		found[host] = true
+	}
+	var result []string
+	for _, host := range hosts {
+		if !found[host] {
+			result = append(result, host)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+func TestClusters(t *testing.T) {
+	tests := []struct {
+		name            string
+		from            model.Cluster
+		to              model.Cluster
+		expected        Diff
+		expectedChanged bool
+		expectedChanges []model.Color
+	}{
+		{
+			name:     "empty",
+			from:     model.NewCluster(),
+			to:       model.NewCluster(),
+			expected: Diff{{Color: model.Blue}, {Color: model.Green}},
+		},
+		{
+			name: "unchanged",
+			from: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"a", "b"}, Version: semver.MustParse("0.1.0")},
+			},
+			to: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"b", "a"}, Version: semver.MustParse("0.1.0")},
+				model.Blue:  model.ClusterGroup{Version: semver.MustParse("0.2.0")},
+			},
+			expected: Diff{
+				{Color: model.Blue, ToVersion: semver.MustParse("0.2.0")},
+				{Color: model.Green, FromCount: 2, ToCount: 2, FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.1.0")},
+			},
+		},
+		{
+			name: "partial_rollout",
+			from: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"a", "b"}, Version: semver.MustParse("0.1.0")},
+			},
+			to: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"b"}, Version: semver.MustParse("0.1.0")},
+				model.Blue:  model.ClusterGroup{Hosts: []string{"d", "c"}, Version: semver.MustParse("0.2.0")},
+			},
+			expected: Diff{
+				{Color: model.Blue, ToCount: 2, ToVersion: semver.MustParse("0.2.0"), AddedHosts: []string{"c", "d"}},
+				{Color: model.Green, FromCount: 2, ToCount: 1, FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.1.0"), RemovedHosts: []string{"a"}},
+			},
+			expectedChanged: true,
+			expectedChanges: []model.Color{model.Blue, model.Green},
+		},
+		{
+			name: "replaced_hosts",
+			from: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"a", "b"}, Version: semver.MustParse("0.1.0")},
+			},
+			to: model.Cluster{
+				model.Green: model.ClusterGroup{Hosts: []string{"a", "c"}, Version: semver.MustParse("0.1.1")},
+			},
+			expected: Diff{
+				{Color: model.Blue},
+				{Color: model.Green, FromCount: 2, ToCount: 2, FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.1.1"), AddedHosts: []string{"c"}, RemovedHosts: []string{"b"}},
+			},
+			expectedChanged: true,
+			expectedChanges: []model.Color{model.Green},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			d := Clusters(test.from, test.to)
+			assert.Equal(test.expected, d)
+			assert.Equal(test.expectedChanged, d.Changed())
+			changes := []model.Color{}
+			for _, group := range d.Changes() {
+				changes = append(changes, group.Color)
+			}
+			if test.expectedChanges == nil {
+				test.expectedChanges = []model.Color{}
+			}
+			assert.Equal(test.expectedChanges, changes)
+		})
+	}
+}
+
+func TestGroupDiff(t *testing.T) {
+	tests := []struct {
+		name                   string
+		group                  GroupDiff
+		expectedCountChanged   bool
+		expectedVersionChanged bool
+	}{
+		{
+			name:  "empty_group_version",
+			group: GroupDiff{FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.2.0")},
+		},
+		{
+			name:                 "emptied",
+			group:                GroupDiff{FromCount: 2, FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.1.0")},
+			expectedCountChanged: true,
+		},
+		{
+			name:                   "in_place_upgrade",
+			group:                  GroupDiff{FromCount: 2, ToCount: 2, FromVersion: semver.MustParse("0.1.0"), ToVersion: semver.MustParse("0.2.0")},
+			expectedVersionChanged: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(test.expectedCountChanged, test.group.CountChanged())
+			assert.Equal(test.expectedVersionChanged, test.group.VersionChanged())
+			assert.Equal(test.expectedCountChanged || test.expectedVersionChanged, test.group.Changed())
+		})
+	}
+}
@@ -9,9 +9,11 @@ import (
 
 var (
 	ErrDetermineGroupFailure = errors.New("failed to determine current group")
+	ErrGroupInUse            = errors.New("can't change the version of a group with nodes")
 )
 
-// BuildEndState is a GoalStateFunc that provides a simple 2 Color Group switch.
+// BuildEndState is a GoalStateFunc that moves the nodes from the current Color Group to the next one in the rotation.
+// Pinned Color Groups keep their count and version.
 func BuildEndState(current model.ClusterState, nodeCount int, version semver.Version) (model.ClusterState, error) {
 	currentGroup, err := current.Group()
 	if err != nil {
@@ -22,7 +24,40 @@ func BuildEndState(current model.ClusterState, nodeCount int, version semver.Ver
 		Count:   0,
 		Version: current[currentGroup].Version, // TODO; should the version stay?
 	}
-	goal[currentGroup.Other()] = model.ClusterGroupState{
+	goal[currentGroup.Next()] = model.ClusterGroupState{
+		Count:   nodeCount,
+		Version: version,
+	}
+	return goal, nil
+}
+
+// BuildDesiredState is a GoalStateFunc that deploys the version to the given Color Group.
+// If the group is Unknown, the current group is used when it already runs the version, so only its node count
+// changes. Otherwise the next group in the rotation is used, like BuildEndState.
+// Deploying to a pinned Color Group leaves the other groups untouched.
+func BuildDesiredState(current model.ClusterState, group model.Color, nodeCount int, version semver.Version) (model.ClusterState, error) {
+	currentGroup, err := current.Group()
+	if err != nil {
+		return model.NewClusterState(), fmt.Errorf("%w: %v", ErrDetermineGroupFailure, err)
+	}
+	if group == model.Unknown {
+		group = currentGroup.Next()
+		if current[currentGroup].Count != 0 && current[currentGroup].Version.EQ(version) {
+			group = currentGroup
+		}
+	}
+	if current[group].Count != 0 && !current[group].Version.EQ(version) {
+		return model.NewClusterState(), fmt.Errorf("%w: %s is at %s", ErrGroupInUse, group, current[group].Version)
+	}
+
+	goal := current.Clone()
+	if group != currentGroup && !group.IsPinned() {
+		goal[currentGroup] = model.ClusterGroupState{
+			Count:   0,
+			Version: current[currentGroup].Version,
+		}
+	}
+	goal[group] = model.ClusterGroupState{
 		Count:   nodeCount,
 		Version: version,
 	}
@@ -147,3 +147,186 @@ func TestDefaultGoal(t *testing.T) {
 		})
 	}
 }
+
+func TestGoalWithGroups(t *testing.T) {
+	defer model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}})
+	red := model.Color("red")
+	canary := model.Color("canary")
+	if err := model.SetColorGroups([]model.ColorGroup{{Name: "red"}, {Name: "blue"}, {Name: "green"}, {Name: "canary", Pinned: true}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		sourceCluster   model.ClusterState
+		expectedCluster model.ClusterState
+		nodeCount       int
+		version         semver.Version
+	}{
+		{
+			name:          "empty_cluster",
+			sourceCluster: model.NewClusterState(),
+			expectedCluster: model.ClusterState{
+				red: model.ClusterGroupState{},
+				model.Blue: model.ClusterGroupState{
+					Count:   3,
+					Version: semver.MustParse("0.1.1"),
+				},
+				model.Green: model.ClusterGroupState{},
+				canary:      model.ClusterGroupState{},
+			},
+			nodeCount: 3,
+			version:   semver.MustParse("0.1.1"),
+		},
+		{
+			name: "wrap_around_with_canary",
+			sourceCluster: model.ClusterState{
+				red:        model.ClusterGroupState{},
+				model.Blue: model.ClusterGroupState{},
+				model.Green: model.ClusterGroupState{
+					Count:   2,
+					Version: semver.MustParse("0.1.0"),
+				},
+				canary: model.ClusterGroupState{
+					Count:   1,
+					Version: semver.MustParse("0.2.0-rc1"),
+				},
+			},
+			expectedCluster: model.ClusterState{
+				red: model.ClusterGroupState{
+					Count:   2,
+					Version: semver.MustParse("0.2.0"),
+				},
+				model.Blue: model.ClusterGroupState{},
+				model.Green: model.ClusterGroupState{
+					Version: semver.MustParse("0.1.0"),
+				},
+				canary: model.ClusterGroupState{
+					Count:   1,
+					Version: semver.MustParse("0.2.0-rc1"),
+				},
+			},
+			nodeCount: 2,
+			version:   semver.MustParse("0.2.0"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			actualCluster, err := BuildEndState(test.sourceCluster, test.nodeCount, test.version)
+			assert.NoError(err)
+			assert.Equal(test.expectedCluster, actualCluster)
+		})
+	}
+}
+
+func TestBuildDesiredState(t *testing.T) {
+	defer model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}})
+	canary := model.Color("canary")
+	if err := model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}, {Name: "canary", Pinned: true}}); err != nil {
+		t.Fatal(err)
+	}
+	greenCluster := model.ClusterState{
+		model.Blue: model.ClusterGroupState{},
+		model.Green: model.ClusterGroupState{
+			Count:   3,
+			Version: semver.MustParse("0.1.1"),
+		},
+		canary: model.ClusterGroupState{},
+	}
+	tests := []struct {
+		name            string
+		sourceCluster   model.ClusterState
+		group           model.Color
+		nodeCount       int
+		version         semver.Version
+		expectedCluster model.ClusterState
+		expectedErr     error
+	}{
+		{
+			name:          "new_version",
+			sourceCluster: greenCluster,
+			nodeCount:     2,
+			version:       semver.MustParse("0.2.0"),
+			expectedCluster: model.ClusterState{
+				model.Blue: model.ClusterGroupState{
+					Count:   2,
+					Version: semver.MustParse("0.2.0"),
+				},
+				model.Green: model.ClusterGroupState{
+					Version: semver.MustParse("0.1.1"),
+				},
+				canary: model.ClusterGroupState{},
+			},
+		},
+		{
+			name:          "scale_in_place",
+			sourceCluster: greenCluster,
+			nodeCount:     5,
+			version:       semver.MustParse("0.1.1"),
+			expectedCluster: model.ClusterState{
+				model.Blue: model.ClusterGroupState{},
+				model.Green: model.ClusterGroupState{
+					Count:   5,
+					Version: semver.MustParse("0.1.1"),
+				},
+				canary: model.ClusterGroupState{},
+			},
+		},
+		{
+			name:          "same_version_other_group",
+			sourceCluster: greenCluster,
+			group:         model.Blue,
+			nodeCount:     3,
+			version:       semver.MustParse("0.1.1"),
+			expectedCluster: model.ClusterState{
+				model.Blue: model.ClusterGroupState{
+					Count:   3,
+					Version: semver.MustParse("0.1.1"),
+				},
+				model.Green: model.ClusterGroupState{
+					Version: semver.MustParse("0.1.1"),
+				},
+				canary: model.ClusterGroupState{},
+			},
+		},
+		{
+			name:          "canary",
+			sourceCluster: greenCluster,
+			group:         canary,
+			nodeCount:     1,
+			version:       semver.MustParse("0.2.0-rc1"),
+			expectedCluster: model.ClusterState{
+				model.Blue: model.ClusterGroupState{},
+				model.Green: model.ClusterGroupState{
+					Count:   3,
+					Version: semver.MustParse("0.1.1"),
+				},
+				canary: model.ClusterGroupState{
+					Count:   1,
+					Version: semver.MustParse("0.2.0-rc1"),
+				},
+			},
+		},
+		{
+			name:            "group_in_use",
+			sourceCluster:   greenCluster,
+			group:           model.Green,
+			nodeCount:       3,
+			version:         semver.MustParse("0.2.0"),
+			expectedCluster: model.NewClusterState(),
+			expectedErr:     ErrGroupInUse,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			actualCluster, err := BuildDesiredState(test.sourceCluster, test.group, test.nodeCount, test.version)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedCluster, actualCluster)
+		})
+	}
+}
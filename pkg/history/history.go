@@ -0,0 +1,216 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+var (
+	ErrNotFound      = errors.New("history record not found")
+	ErrInvalidRecord = errors.New("invalid history record")
+)
+
+// Operation is the carousel operation of a Record.
+type Operation string
+
+const (
+	Rollout Operation = "rollout"
+	Resume  Operation = "resume"
+	Apply   Operation = "apply"
+)
+
+// Outcome is the result of the operation of a Record.
+type Outcome string
+
+const (
+	Succeeded Outcome = "succeeded"
+	Failed    Outcome = "failed"
+)
+
+// Record is the history of a single rollout, resume or apply.
+type Record struct {
+	// ID is assigned by the Store, starting at 1.
+	ID int `json:"id,omitempty"`
+
+	Operation Operation `json:"operation"`
+
+	// Workspace is the workspace the operation ran in.
+	Workspace string `json:"workspace,omitempty"`
+
+	// Operator is the user that ran the operation.
+	Operator string `json:"operator,omitempty"`
+
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+
+	// From is the cluster before the operation, empty if the cluster couldn't be read.
+	From model.ClusterState `json:"from"`
+
+	// To is the goal of the operation, empty if the goal couldn't be built.
+	To model.ClusterState `json:"to"`
+
+	// Steps are the steps completed.
+	Steps []model.Step `json:"steps"`
+
+	// TaintedHosts are the hosts that failed validation and were tainted.
+	TaintedHosts []string `json:"tainted_hosts,omitempty"`
+
+	Outcome Outcome `json:"outcome"`
+
+	// Error is the cause of a failed operation.
+	Error string `json:"error,omitempty"`
+
+	// LogDir is the directory of the output of the commands run by the operation, if kept.
+	LogDir string `json:"log_dir,omitempty"`
+}
+
+// HasVersion returns true if the version was running before or after the operation.
+func (r Record) HasVersion(version string) bool {
+	for _, state := range []model.ClusterState{r.From, r.To} {
+		for _, group := range state {
+			if group.Count > 0 && group.Version.String() == version {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Filter selects Records from a Store, empty fields match every Record.
+type Filter struct {
+	Workspace string
+	Version   string
+}
+
+// Match returns true if the Record matches every field of the Filter.
+func (f Filter) Match(record Record) bool {
+	if f.Workspace != "" && record.Workspace != f.Workspace {
+		return false
+	}
+	if f.Version != "" && !record.HasVersion(f.Version) {
+		return false
+	}
+	return true
+}
+
+// Store keeps Records in a local JSON-lines file, one Record per line.
+// The file is locked while a Record is appended, so runs sharing a Store get distinct IDs. The lock is advisory,
+// it is not taken on Windows and may not be honored by network filesystems.
+type Store struct {
+	path string
+}
+
+// NewStore builds a Store, the file and its directory are created on the first Append.
+func NewStore(path string) Store {
+	return Store{path: path}
+}
+
+// Append adds the Record to the Store and returns it with its ID.
+func (s Store) Append(record Record) (Record, error) {
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return record, err
+		}
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return record, err
+	}
+	defer file.Close()
+	// the ID is the number of Records, so the file is locked until the Record is written.
+	if err := lockFile(file); err != nil {
+		return record, err
+	}
+	defer unlockFile(file)
+
+	records, err := s.read()
+	if err != nil {
+		return record, err
+	}
+	record.ID = len(records) + 1
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return record, err
+	}
+	_, err = file.Write(append(data, '\n'))
+	return record, err
+}
+
+// List returns the Records matching the Filter, oldest first.
+// A Store without a file has no Records.
+func (s Store) List(filter Filter) ([]Record, error) {
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]Record, 0, len(records))
+	for _, record := range records {
+		if filter.Match(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched, nil
+}
+
+// Get returns the Record with the ID.
+func (s Store) Get(id int) (Record, error) {
+	records, err := s.read()
+	if err != nil {
+		return Record{}, err
+	}
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+	return Record{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+func (s Store) read() ([]Record, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%w: line %d %v", ErrInvalidRecord, line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// Recorder appends Records to a Store with the Workspace and Operator of the current run of carousel.
+type Recorder struct {
+	Store     Store
+	Workspace string
+	Operator  string
+}
+
+// Record adds the Workspace and Operator to the Record and appends it to the Store.
+func (r Recorder) Record(record Record) error {
+	record.Workspace = r.Workspace
+	record.Operator = r.Operator
+	_, err := r.Store.Append(record)
+	return err
+}
@@ -0,0 +1,158 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+func buildRecord(workspace string, from string, to string) Record {
+	return Record{
+		Operation: Rollout,
+		Workspace: workspace,
+		StartedAt: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		EndedAt:   time.Date(2021, 3, 4, 5, 16, 7, 0, time.UTC),
+		From: model.ClusterState{
+			model.Green: model.ClusterGroupState{Count: 2, Version: semver.MustParse(from)},
+			model.Blue:  model.ClusterGroupState{},
+		},
+		To: model.ClusterState{
+			model.Blue:  model.ClusterGroupState{Count: 2, Version: semver.MustParse(to)},
+			model.Green: model.ClusterGroupState{},
+		},
+		Steps:   []model.Step{{model.Blue: 1, model.Green: 2}},
+		Outcome: Succeeded,
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "history", "history.jsonl"))
+
+	records, err := store.List(Filter{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	for _, record := range []Record{
+		buildRecord("dev", "0.1.0", "0.2.0"),
+		buildRecord("prod", "0.1.0", "0.2.0"),
+		buildRecord("dev", "0.2.0", "0.3.0"),
+	} {
+		_, err := store.Append(record)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		expectedIDs []int
+	}{
+		{
+			name:        "all",
+			expectedIDs: []int{1, 2, 3},
+		},
+		{
+			name:        "workspace",
+			filter:      Filter{Workspace: "dev"},
+			expectedIDs: []int{1, 3},
+		},
+		{
+			name:        "from_or_to_version",
+			filter:      Filter{Version: "0.2.0"},
+			expectedIDs: []int{1, 2, 3},
+		},
+		{
+			name:        "workspace_and_version",
+			filter:      Filter{Workspace: "prod", Version: "0.3.0"},
+			expectedIDs: []int{},
+		},
+		{
+			name:        "version_without_nodes",
+			filter:      Filter{Version: "0.0.0"},
+			expectedIDs: []int{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			records, err := store.List(test.filter)
+			assert.NoError(err)
+			ids := []int{}
+			for _, record := range records {
+				ids = append(ids, record.ID)
+			}
+			assert.Equal(test.expectedIDs, ids)
+		})
+	}
+
+	record, err := store.Get(2)
+	assert.NoError(t, err)
+	expected := buildRecord("prod", "0.1.0", "0.2.0")
+	expected.ID = 2
+	assert.Equal(t, expected, record)
+
+	_, err = store.Get(4)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreAppendWaitsForLock(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the store isn't locked on windows")
+	}
+	assert := assert.New(t)
+	store := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	_, err := store.Append(Record{Operation: Rollout, Outcome: Succeeded})
+	assert.NoError(err)
+
+	// another run holds the lock while it appends.
+	other, err := os.OpenFile(store.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if !assert.NoError(err) {
+		return
+	}
+	defer other.Close()
+	assert.NoError(lockFile(other))
+
+	appended := make(chan Record)
+	go func() {
+		record, err := store.Append(Record{Operation: Resume, Outcome: Succeeded})
+		assert.NoError(err)
+		appended <- record
+	}()
+	select {
+	case <-appended:
+		assert.Fail("append didn't wait for the lock")
+		return
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = other.Write([]byte(`{"operation":"apply","outcome":"failed"}` + "\n"))
+	assert.NoError(err)
+	assert.NoError(unlockFile(other))
+	assert.Equal(3, (<-appended).ID)
+}
+
+func TestStoreInvalidRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	assert.NoError(t, os.WriteFile(path, []byte("{\"id\": 1}\n\n{\"id\": \n"), 0644))
+
+	_, err := NewStore(path).List(Filter{})
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+	assert.Contains(t, err.Error(), "line 3")
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	store := NewStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	recorder := Recorder{Store: store, Workspace: "dev", Operator: "jane"}
+
+	assert.NoError(recorder.Record(buildRecord("", "0.1.0", "0.2.0")))
+	record, err := store.Get(1)
+	assert.NoError(err)
+	assert.Equal("dev", record.Workspace)
+	assert.Equal("jane", record.Operator)
+}
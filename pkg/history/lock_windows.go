@@ -0,0 +1,12 @@
+package history
+
+import "os"
+
+// lockFile doesn't lock on Windows, concurrent runs sharing a Store can record the same ID.
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
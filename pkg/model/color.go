@@ -1,29 +1,136 @@
 package model
 
-//go:generate go-enum -f=$GOFILE --marshal --names  --noprefix
-
-// Color is simple type that serves to group deployment groups
-// In our case, only two groups should be needed
-/*
-ENUM(
-blue
-green
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 )
-*/
-type Color int
 
-const Unknown = Color(-1)
+var (
+	errInvalidColorName  = errors.New("invalid color group name")
+	errDuplicateColor    = errors.New("duplicate color group")
+	errTooFewColorGroups = errors.New("at least two unpinned color groups are required")
+)
+
+var colorNameRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Color is the name of a deployment group.
+// By default only the blue and green groups exist, more can be configured with SetColorGroups.
+type Color string
+
+const (
+	Unknown = Color("")
+	Blue    = Color("blue")
+	Green   = Color("green")
+)
+
+// ColorGroup is the configuration of a single deployment group.
+type ColorGroup struct {
+	// Name of the group, for example blue, green or canary.
+	Name string
+
+	// Pinned groups keep their count and version during a rollout and are not considered when checking if
+	// the cluster is in a clean state. This is useful for a dedicated canary group or a group kept for rollback.
+	Pinned bool
+}
 
 // ValidColors is an Array of Valid Color Groups
 var ValidColors = []Color{Blue, Green}
 
-// Other returns the complement color between Green and Blue
-func (c Color) Other() Color {
-	switch c {
-	case Green:
-		return Blue
-	case Blue:
-		return Green
+var pinnedColors = map[Color]bool{}
+
+// SetColorGroups replaces the ValidColors with the given groups.
+// At least two groups must not be pinned and the names must be unique.
+func SetColorGroups(groups []ColorGroup) error {
+	colors := make([]Color, 0, len(groups))
+	pinned := map[Color]bool{}
+	seen := map[Color]bool{}
+	for _, group := range groups {
+		if !colorNameRegex.MatchString(group.Name) {
+			return fmt.Errorf("%w: %q", errInvalidColorName, group.Name)
+		}
+		color := Color(group.Name)
+		if seen[color] {
+			return fmt.Errorf("%w: %s", errDuplicateColor, color)
+		}
+		seen[color] = true
+		colors = append(colors, color)
+		if group.Pinned {
+			pinned[color] = true
+		}
+	}
+	if len(colors)-len(pinned) < 2 {
+		return errTooFewColorGroups
+	}
+	ValidColors = colors
+	pinnedColors = pinned
+	return nil
+}
+
+// RotationColors returns the ValidColors that are not pinned, in order.
+func RotationColors() []Color {
+	colors := make([]Color, 0, len(ValidColors))
+	for _, color := range ValidColors {
+		if !color.IsPinned() {
+			colors = append(colors, color)
+		}
+	}
+	return colors
+}
+
+// ColorNames returns a list of possible string values of Color.
+func ColorNames() []string {
+	names := make([]string, len(ValidColors))
+	for i, color := range ValidColors {
+		names[i] = color.String()
+	}
+	return names
+}
+
+// ParseColor attempts to convert a string to a Color
+func ParseColor(name string) (Color, error) {
+	for _, color := range ValidColors {
+		if string(color) == name {
+			return color, nil
+		}
+	}
+	return Unknown, fmt.Errorf("%s is not a valid Color, try [%s]", name, strings.Join(ColorNames(), ", "))
+}
+
+// String implements the Stringer interface.
+func (c Color) String() string {
+	return string(c)
+}
+
+// IsPinned returns true if the Color Group is not part of the rollout rotation.
+func (c Color) IsPinned() bool {
+	return pinnedColors[c]
+}
+
+// Next returns the Color Group after c in the rotation, wrapping around to the first group.
+// Unknown is returned for pinned or invalid groups.
+func (c Color) Next() Color {
+	rotation := RotationColors()
+	for i, color := range rotation {
+		if color == c {
+			return rotation[(i+1)%len(rotation)]
+		}
 	}
 	return Unknown
 }
+
+// MarshalText implements the text marshaller method
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements the text unmarshaller method
+func (c *Color) UnmarshalText(text []byte) error {
+	tmp, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = tmp
+	return nil
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var defaultGroups = []ColorGroup{{Name: "blue"}, {Name: "green"}}
+
+func TestSetColorGroups(t *testing.T) {
+	tests := []struct {
+		name             string
+		groups           []ColorGroup
+		expectedColors   []Color
+		expectedRotation []Color
+		expectedErr      error
+	}{
+		{
+			name:             "default",
+			groups:           defaultGroups,
+			expectedColors:   []Color{Blue, Green},
+			expectedRotation: []Color{Blue, Green},
+		},
+		{
+			name:             "three_groups",
+			groups:           []ColorGroup{{Name: "red"}, {Name: "blue"}, {Name: "green"}},
+			expectedColors:   []Color{"red", Blue, Green},
+			expectedRotation: []Color{"red", Blue, Green},
+		},
+		{
+			name:             "pinned_canary",
+			groups:           []ColorGroup{{Name: "a"}, {Name: "canary", Pinned: true}, {Name: "b"}},
+			expectedColors:   []Color{"a", "canary", "b"},
+			expectedRotation: []Color{"a", "b"},
+		},
+		{
+			name:             "too_few",
+			groups:           []ColorGroup{{Name: "blue"}, {Name: "canary", Pinned: true}},
+			expectedColors:   []Color{Blue, Green},
+			expectedRotation: []Color{Blue, Green},
+			expectedErr:      errTooFewColorGroups,
+		},
+		{
+			name:             "duplicate",
+			groups:           []ColorGroup{{Name: "blue"}, {Name: "blue"}},
+			expectedColors:   []Color{Blue, Green},
+			expectedRotation: []Color{Blue, Green},
+			expectedErr:      errDuplicateColor,
+		},
+		{
+			name:             "invalid_name",
+			groups:           []ColorGroup{{Name: "blue"}, {Name: "green"}, {Name: "not valid"}},
+			expectedColors:   []Color{Blue, Green},
+			expectedRotation: []Color{Blue, Green},
+			expectedErr:      errInvalidColorName,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			defer SetColorGroups(defaultGroups)
+
+			err := SetColorGroups(test.groups)
+			if test.expectedErr != nil {
+				assert.True(errors.Is(err, test.expectedErr))
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expectedColors, ValidColors)
+			assert.Equal(test.expectedRotation, RotationColors())
+		})
+	}
+}
+
+func TestColorNext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(Green, Blue.Next())
+	assert.Equal(Blue, Green.Next())
+	assert.Equal(Unknown, Color("purple").Next())
+
+	defer SetColorGroups(defaultGroups)
+	assert.NoError(SetColorGroups([]ColorGroup{{Name: "red"}, {Name: "canary", Pinned: true}, {Name: "blue"}, {Name: "green"}}))
+	assert.Equal(Blue, Color("red").Next())
+	assert.Equal(Green, Blue.Next())
+	assert.Equal(Color("red"), Green.Next())
+	assert.Equal(Unknown, Color("canary").Next())
+}
+
+func TestParseColor(t *testing.T) {
+	assert := assert.New(t)
+	color, err := ParseColor("green")
+	assert.NoError(err)
+	assert.Equal(Green, color)
+
+	_, err = ParseColor("red")
+	assert.Error(err)
+
+	var parsed Color
+	assert.Error(parsed.UnmarshalText([]byte("red")))
+	assert.NoError(parsed.UnmarshalText([]byte("blue")))
+	assert.Equal(Blue, parsed)
+}
+
+func TestIsCleanStateWithPinned(t *testing.T) {
+	assert := assert.New(t)
+	defer SetColorGroups(defaultGroups)
+	assert.NoError(SetColorGroups([]ColorGroup{{Name: "blue"}, {Name: "green"}, {Name: "canary", Pinned: true}}))
+
+	cs := ClusterState{
+		Blue:            ClusterGroupState{Count: 3},
+		Green:           ClusterGroupState{},
+		Color("canary"): ClusterGroupState{Count: 1},
+	}
+	assert.True(cs.IsCleanState())
+	group, err := cs.Group()
+	assert.NoError(err)
+	assert.Equal(Blue, group)
+
+	cs[Green] = ClusterGroupState{Count: 1}
+	assert.False(cs.IsCleanState())
+}
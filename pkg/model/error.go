@@ -42,12 +42,14 @@ type StepError struct {
 	OriginalCluster    Cluster      `json:"original_cluster"`
 	StartingColorGroup Color        `json:"starting_group"`
 	GoalClusterState   ClusterState `json:"goal_state"`
+	// Replace are the resources to replace with the next apply, if the failed apply was replacing them.
+	Replace []string `json:"replace,omitempty"`
 }
 
 func (e StepError) Error() string {
 	return e.Cause.Error()
 }
-func (e *StepError) Unwrap() error {
+func (e StepError) Unwrap() error {
 	return e.Cause
 }
 
@@ -60,6 +62,6 @@ func (e RunnableError) Error() string {
 	return e.ResultErr.Error()
 }
 
-func (e *RunnableError) Unwrap() error {
+func (e RunnableError) Unwrap() error {
 	return e.ResultErr
 }
@@ -31,4 +31,30 @@ type BinaryConfig struct {
 	// WorkingDirectory is the working directory to run the specified Binary.
 	// If empty the current directory will be used.
 	WorkingDirectory string
+
+	// VarFiles are variable files given to each apply, before the Args so the Args take precedence.
+	// Relative paths are relative to the WorkingDirectory, e.g. -var-file=prod.tfvars.
+	VarFiles []string
+
+	// Init configures the init run once before the workspace is selected.
+	Init InitConfig
+}
+
+// InitConfig configures terraform init.
+type InitConfig struct {
+	// BackendConfigFiles are files of a partial backend configuration, each passed with -backend-config.
+	BackendConfigFiles []string
+
+	// BackendConfig are the values of a partial backend configuration, after the BackendConfigFiles so they take
+	// precedence. Each is passed as -backend-config=key=value, the values are hidden in the command that is output.
+	BackendConfig []ValuePair
+
+	// Upgrade upgrades the modules and providers with -upgrade.
+	Upgrade bool
+
+	// Reconfigure ignores the saved backend configuration with -reconfigure.
+	Reconfigure bool
+
+	// Args are additional arguments of init, e.g. -lockfile=readonly.
+	Args []string
 }
@@ -14,6 +14,31 @@ var (
 type ClusterGroup struct {
 	Hosts   []string       `json:"hosts"`
 	Version semver.Version `json:"version"`
+	// Details is the metadata of each of the Hosts, in the same order.
+	// Empty if the hostnames output only lists hostnames.
+	Details []Host `json:"details,omitempty"`
+}
+
+// Host returns the metadata of the host, only the FQDN is set if the ClusterGroup has no Details.
+func (g ClusterGroup) Host(fqdn string) Host {
+	for _, host := range g.Details {
+		if host.FQDN == fqdn {
+			return host
+		}
+	}
+	return Host{FQDN: fqdn}
+}
+
+// Host is the metadata of a single node, from a hostnames output listing objects instead of hostnames, e.g.
+// {fqdn = "a.example.com", ip = "10.0.0.1", zone = "us-east-1a", address = "aws_instance.server[0]"}.
+type Host struct {
+	FQDN string `json:"fqdn"`
+	IP   string `json:"ip,omitempty"`
+	Zone string `json:"zone,omitempty"`
+	// Address is the terraform resource address of the node.
+	Address string `json:"address,omitempty"`
+	// Key is the key of the node if the output is a map.
+	Key string `json:"key,omitempty"`
 }
 
 type ClusterGroupState struct {
@@ -21,6 +46,16 @@ type ClusterGroupState struct {
 	Version semver.Version `json:"version"`
 }
 
+// DesiredState describes the cluster a rollout should end with.
+type DesiredState struct {
+	// Group is the Color Group to deploy to. If Unknown, the group is chosen by the goal.
+	Group Color
+	// Count is the number of nodes of the Group.
+	Count int
+	// Version is the version of the Group.
+	Version semver.Version
+}
+
 // Cluster is a representation of how Cluster is by the hostnames and version deployed.
 // Note the Color Groups MUST be the same in both Hosts and Version.
 type Cluster map[Color]ClusterGroup
@@ -43,8 +78,10 @@ type Step map[Color]int
 
 func (s Step) String() string {
 	str := ""
-	for color, count := range s {
-		str += fmt.Sprintf("%s:%d ", strings.Title(color.String()), count)
+	for _, color := range ValidColors {
+		if count, ok := s[color]; ok {
+			str += fmt.Sprintf("%s:%d ", strings.Title(color.String()), count)
+		}
 	}
 	return str
 }
@@ -55,8 +92,10 @@ type ClusterState map[Color]ClusterGroupState
 
 func (cs ClusterState) String() string {
 	str := ""
-	for color, group := range cs {
-		str += fmt.Sprintf("%s@%s:%d ", strings.Title(color.String()), group.Version, group.Count)
+	for _, color := range ValidColors {
+		if group, ok := cs[color]; ok {
+			str += fmt.Sprintf("%s@%s:%d ", strings.Title(color.String()), group.Version, group.Count)
+		}
 	}
 	return str
 }
@@ -80,11 +119,11 @@ func (cs ClusterState) IsEmpty() bool {
 	return true
 }
 
-// IsCleanState returns true if only one Color Group has nodes.
+// IsCleanState returns true if only one Color Group has nodes, ignoring pinned Color Groups.
 func (cs ClusterState) IsCleanState() bool {
 	groupsWithNodes := 0
-	for _, group := range cs {
-		if group.Count != 0 {
+	for color, group := range cs {
+		if group.Count != 0 && !color.IsPinned() {
 			groupsWithNodes++
 		}
 	}
@@ -94,16 +133,18 @@ func (cs ClusterState) IsCleanState() bool {
 
 // Group returns the Color Group if the ClusterState is in a clean state,  (see IsCleanState)
 // otherwise returns the Unknown Color Group.
+// If no unpinned Color Group has nodes, the first group of the rotation is returned.
 func (cs ClusterState) Group() (Color, error) {
 	if !cs.IsCleanState() {
 		return Unknown, errNotCleanClusterState
 	}
-	for color, group := range cs {
-		if group.Count != 0 {
+	rotation := RotationColors()
+	for _, color := range rotation {
+		if cs[color].Count != 0 {
 			return color, nil
 		}
 	}
-	return ValidColors[0], nil
+	return rotation[0], nil
 }
 
 // EqualNodeCount returns true if both structs have the same NodeCount map
@@ -118,6 +159,21 @@ func (cs ClusterState) EqualNodeCount(other ClusterState) bool {
 	}
 	return true
 }
+
+// Equal returns true if both ClusterStates have the same node count for each Color Group and the same version
+// for each Color Group with nodes.
+func (cs ClusterState) Equal(other ClusterState) bool {
+	if !cs.EqualNodeCount(other) {
+		return false
+	}
+	for color, group := range cs {
+		if group.Count != 0 && !group.Version.EQ(other[color].Version) {
+			return false
+		}
+	}
+	return true
+}
+
 func (cs ClusterState) EqualStep(step Step) bool {
 	if len(cs) != len(step) {
 		return false
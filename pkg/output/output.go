@@ -0,0 +1,271 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unknown output format")
+	ErrInvalidTemplate = errors.New("invalid output template")
+)
+
+// Format is the name of an output format.
+type Format string
+
+const (
+	Table    Format = "table"
+	JSON     Format = "json"
+	YAML     Format = "yaml"
+	CSV      Format = "csv"
+	Template Format = "template"
+)
+
+// templatePrefixes start a go template format, e.g. template={{.blue.count}}.
+var templatePrefixes = []string{"template=", "go-template="}
+
+// Tabular is a value that can be output as rows, for the table and csv formats.
+type Tabular interface {
+	Header() []string
+	Rows() [][]string
+}
+
+// Rows is a simple Tabular.
+type Rows struct {
+	Columns []string
+	Values  [][]string
+}
+
+func (r Rows) Header() []string {
+	return r.Columns
+}
+
+func (r Rows) Rows() [][]string {
+	return r.Values
+}
+
+// Formatter outputs values in a Format.
+type Formatter struct {
+	format Format
+	tmpl   *template.Template
+}
+
+// Formats lists the names accepted by NewFormatter.
+func Formats() []string {
+	return []string{string(Table), string(JSON), string(YAML), string(CSV), "template=<go template>"}
+}
+
+// NewFormatter builds a Formatter from the name of a Format.
+// A go template is given as template=<go template>, the template is executed with the json encoding of the value
+// being output, so fields are referenced by their json names like {{.blue.version}}.
+func NewFormatter(name string) (Formatter, error) {
+	for _, prefix := range templatePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			tmpl, err := template.New("output").Funcs(template.FuncMap{"json": toJSON}).Parse(strings.TrimPrefix(name, prefix))
+			if err != nil {
+				return Formatter{}, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+			}
+			return Formatter{format: Template, tmpl: tmpl}, nil
+		}
+	}
+	switch format := Format(name); format {
+	case Table, JSON, YAML, CSV:
+		return Formatter{format: format}, nil
+	default:
+		return Formatter{}, fmt.Errorf("%w: %s, try [%s]", ErrUnknownFormat, name, strings.Join(Formats(), ", "))
+	}
+}
+
+// Output returns the value in the Format, the table and csv formats use the rows of table.
+// The json, yaml and template formats use the json encoding of value, so json tags apply to all of them.
+func (f Formatter) Output(value interface{}, table Tabular) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	switch f.format {
+	case JSON:
+		var data []byte
+		data, err = json.MarshalIndent(value, "", "  ")
+		buf.Write(data)
+	case YAML:
+		err = writeYAML(&buf, value)
+	case CSV:
+		err = writeCSV(&buf, table, true)
+	case Template:
+		err = f.execute(&buf, value)
+	default:
+		err = writeTable(&buf, table)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// Stream outputs a sequence of values, one after the other.
+// json is output as JSON lines and yaml as documents. The header of table and csv is only output
+// when the columns change, and the table columns are kept aligned with the previous rows.
+type Stream struct {
+	formatter Formatter
+	writer    io.Writer
+	header    []string
+	widths    []int
+}
+
+// NewStream builds a Stream writing to w.
+func NewStream(formatter Formatter, w io.Writer) *Stream {
+	return &Stream{formatter: formatter, writer: w}
+}
+
+// Write outputs the next value.
+func (s *Stream) Write(value interface{}, table Tabular) error {
+	var header bool
+	if table != nil && !equal(s.header, table.Header()) {
+		header = true
+		s.header = table.Header()
+		s.widths = nil
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch s.formatter.format {
+	case JSON:
+		var data []byte
+		if data, err = json.Marshal(value); err == nil {
+			buf.Write(append(data, '\n'))
+		}
+	case YAML:
+		buf.WriteString("---\n")
+		err = writeYAML(&buf, value)
+	case CSV:
+		err = writeCSV(&buf, table, header)
+	case Template:
+		if err = s.formatter.execute(&buf, value); err == nil && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	default:
+		s.writeAligned(&buf, table, header)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(buf.Bytes())
+	return err
+}
+
+// writeAligned pads each cell to the widest cell of its column so far, as each write can't see the next rows.
+func (s *Stream) writeAligned(w io.Writer, table Tabular, header bool) {
+	rows := table.Rows()
+	if header {
+		rows = append([][]string{table.Header()}, rows...)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(s.widths) {
+				s.widths = append(s.widths, 0)
+			}
+			if len(cell) > s.widths[i] {
+				s.widths[i] = len(cell)
+			}
+		}
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+			if i < len(row)-1 {
+				cells[i] = fmt.Sprintf("%-*s", s.widths[i], cell)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+}
+
+func equal(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Formatter) execute(w io.Writer, value interface{}) error {
+	generic, err := toGeneric(value)
+	if err != nil {
+		return err
+	}
+	return f.tmpl.Execute(w, generic)
+}
+
+// toGeneric goes through json, so json tags and marshalers like semver.Version are used.
+func toGeneric(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(data, &generic)
+	return generic, err
+}
+
+func writeYAML(w io.Writer, value interface{}) error {
+	generic, err := toGeneric(value)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(generic); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func writeCSV(w io.Writer, table Tabular, header bool) error {
+	writer := csv.NewWriter(w)
+	if header {
+		if err := writer.Write(table.Header()); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(table.Rows()); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func writeTable(w io.Writer, table Tabular) error {
+	var buf bytes.Buffer
+	writer := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(table.Header(), "\t"))
+	for _, row := range table.Rows() {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	// empty cells at the end of a row are still padded.
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type group struct {
+	Name    string         `json:"name"`
+	Count   int            `json:"count"`
+	Version semver.Version `json:"version"`
+}
+
+var groups = []group{
+	{Name: "blue", Count: 2, Version: semver.MustParse("0.2.0")},
+	{Name: "green", Count: 0, Version: semver.MustParse("0.1.0")},
+}
+
+var groupRows = Rows{
+	Columns: []string{"GROUP", "COUNT", "VERSION"},
+	Values: [][]string{
+		{"blue", "2", "0.2.0"},
+		{"green", "0", "0.1.0"},
+	},
+}
+
+func TestFormatter(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:   "table",
+			format: "table",
+			expected: "GROUP  COUNT  VERSION\n" +
+				"blue   2      0.2.0\n" +
+				"green  0      0.1.0",
+		},
+		{
+			name:     "json",
+			format:   "json",
+			expected: "[\n  {\n    \"name\": \"blue\",\n    \"count\": 2,\n    \"version\": \"0.2.0\"\n  },\n  {\n    \"name\": \"green\",\n    \"count\": 0,\n    \"version\": \"0.1.0\"\n  }\n]",
+		},
+		{
+			name:     "yaml",
+			format:   "yaml",
+			expected: "- count: 2\n  name: blue\n  version: 0.2.0\n- count: 0\n  name: green\n  version: 0.1.0",
+		},
+		{
+			name:     "csv",
+			format:   "csv",
+			expected: "GROUP,COUNT,VERSION\nblue,2,0.2.0\ngreen,0,0.1.0",
+		},
+		{
+			name:     "template",
+			format:   "template={{range .}}{{.name}}={{.version}} {{end}}",
+			expected: "blue=0.2.0 green=0.1.0 ",
+		},
+		{
+			name:     "go_template_json",
+			format:   "go-template={{json (index . 0)}}",
+			expected: `{"count":2,"name":"blue","version":"0.2.0"}`,
+		},
+		{
+			name:        "invalid_template",
+			format:      "template={{range .}",
+			expectedErr: ErrInvalidTemplate,
+		},
+		{
+			name:        "unknown",
+			format:      "xml",
+			expectedErr: ErrUnknownFormat,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			formatter, err := NewFormatter(test.format)
+			if test.expectedErr != nil {
+				assert.ErrorIs(err, test.expectedErr)
+				return
+			}
+			assert.NoError(err)
+			result, err := formatter.Output(groups, groupRows)
+			assert.NoError(err)
+			assert.Equal(test.expected, result)
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:     "json_lines",
+			format:   "json",
+			expected: "{\"name\":\"blue\",\"count\":2,\"version\":\"0.2.0\"}\n{\"name\":\"green\",\"count\":0,\"version\":\"0.1.0\"}\n",
+		},
+		{
+			name:     "yaml_documents",
+			format:   "yaml",
+			expected: "---\ncount: 2\nname: blue\nversion: 0.2.0\n---\ncount: 0\nname: green\nversion: 0.1.0\n",
+		},
+		{
+			name:     "csv_header_once",
+			format:   "csv",
+			expected: "GROUP,COUNT,VERSION\nblue,2,0.2.0\ngreen,0,0.1.0\n",
+		},
+		{
+			name:   "table_header_once",
+			format: "table",
+			expected: "GROUP  COUNT  VERSION\n" +
+				"blue   2      0.2.0\n" +
+				"green  0      0.1.0\n",
+		},
+		{
+			name:     "template_lines",
+			format:   "template={{.name}}",
+			expected: "blue\ngreen\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			formatter, err := NewFormatter(test.format)
+			assert.NoError(err)
+
+			var buf bytes.Buffer
+			stream := NewStream(formatter, &buf)
+			for i, g := range groups {
+				assert.NoError(stream.Write(g, Rows{Columns: groupRows.Columns, Values: groupRows.Values[i : i+1]}))
+			}
+			assert.Equal(test.expected, buf.String())
+		})
+	}
+}
+
+func TestStreamHeaderChange(t *testing.T) {
+	assert := assert.New(t)
+	formatter, err := NewFormatter("table")
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	stream := NewStream(formatter, &buf)
+	assert.NoError(stream.Write(nil, Rows{Columns: []string{"STEP", "BLUE"}, Values: [][]string{{"1/2", "0"}}}))
+	assert.NoError(stream.Write(nil, Rows{Columns: []string{"STEP", "BLUE"}, Values: [][]string{{"2/2", "10"}}}))
+	assert.NoError(stream.Write(nil, Rows{Columns: []string{"OUTCOME"}, Values: [][]string{{"succeeded"}}}))
+	assert.Equal("STEP  BLUE\n1/2   0\n2/2   10\nOUTCOME\nsucceeded\n", buf.String())
+}
+
+func TestTableEmptyCells(t *testing.T) {
+	assert := assert.New(t)
+	rows := Rows{
+		Columns: []string{"HOST", "IP", "ZONE"},
+		Values: [][]string{
+			{"a.example.com", "10.0.0.1", "us-east-1a"},
+			{"b.example.com", "", ""},
+		},
+	}
+	formatter, err := NewFormatter("table")
+	assert.NoError(err)
+	result, err := formatter.Output(nil, rows)
+	assert.NoError(err)
+	assert.Equal("HOST           IP        ZONE\n"+
+		"a.example.com  10.0.0.1  us-east-1a\n"+
+		"b.example.com", result)
+
+	var buf bytes.Buffer
+	assert.NoError(NewStream(formatter, &buf).Write(nil, rows))
+	assert.Equal(result+"\n", buf.String())
+}
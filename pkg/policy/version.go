@@ -0,0 +1,130 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+var (
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+	ErrDowngrade         = errors.New("version is older than the current version")
+	ErrSameVersion       = errors.New("version is the same as the current version")
+	ErrPrerelease        = errors.New("prerelease versions are not allowed")
+	ErrBuildMetadata     = errors.New("build metadata is not allowed")
+	ErrNotInConstraint   = errors.New("version does not match the constraint")
+)
+
+// partialVersionRegex matches a comparator followed by a version missing the minor or patch, like >=1.4 or <2.
+var partialVersionRegex = regexp.MustCompile(`^([<>=!]*)(\d+)(\.\d+)?$`)
+
+// VersionConfig configures which versions can be rolled out.
+type VersionConfig struct {
+	// AllowDowngrade allows rolling out a version older than the current version.
+	AllowDowngrade bool
+
+	// RejectPrerelease refuses versions with a prerelease, like 1.2.3-rc1.
+	RejectPrerelease bool
+
+	// RejectBuildMetadata refuses versions with build metadata, like 1.2.3+build.5.
+	RejectBuildMetadata bool
+
+	// Constraint is an optional range the version must match, e.g. >=1.4 <2.
+	// Refer to https://github.com/blang/semver#ranges for the syntax, partial versions are completed with zeros.
+	Constraint string
+}
+
+// VersionPolicy checks a version against a VersionConfig before a rollout.
+// The zero value refuses downgrades and the same version, and allows everything else.
+type VersionPolicy struct {
+	config     VersionConfig
+	constraint semver.Range
+}
+
+// NewVersionPolicy builds a VersionPolicy, an error is returned if the constraint can't be parsed.
+func NewVersionPolicy(config VersionConfig) (VersionPolicy, error) {
+	policy := VersionPolicy{config: config}
+	if strings.TrimSpace(config.Constraint) != "" {
+		constraint, err := semver.ParseRange(completeRange(config.Constraint))
+		if err != nil {
+			return VersionPolicy{}, fmt.Errorf("%w: %v", ErrInvalidConstraint, err)
+		}
+		policy.constraint = constraint
+	}
+	return policy, nil
+}
+
+// Check returns the violations of rolling out the version to the current ClusterState as model.Errors,
+// the violations of CheckVersion followed by the violations of CheckCurrent.
+func (p VersionPolicy) Check(current model.ClusterState, version semver.Version) error {
+	var errs model.Errors
+	for _, err := range []error{p.CheckVersion(version), p.CheckCurrent(current, version)} {
+		if err != nil {
+			errs = append(errs, err.(model.Errors)...)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// CheckVersion returns the violations of the version as model.Errors, the prerelease, build metadata and constraint
+// don't depend on the cluster so they can be checked before anything is run.
+func (p VersionPolicy) CheckVersion(version semver.Version) error {
+	var errs model.Errors
+	if p.config.RejectPrerelease && len(version.Pre) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrPrerelease, version))
+	}
+	if p.config.RejectBuildMetadata && len(version.Build) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrBuildMetadata, version))
+	}
+	if p.constraint != nil && !p.constraint(version) {
+		errs = append(errs, fmt.Errorf("%w: %s does not match %s", ErrNotInConstraint, version, p.config.Constraint))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// CheckCurrent returns the violations of rolling out the version to the current ClusterState as model.Errors,
+// a downgrade or the same version. Nothing is checked if the cluster has no nodes.
+func (p VersionPolicy) CheckCurrent(current model.ClusterState, version semver.Version) error {
+	var errs model.Errors
+	if group, err := current.Group(); err == nil && current[group].Count > 0 {
+		currentVersion := current[group].Version
+		switch {
+		case version.LT(currentVersion) && !p.config.AllowDowngrade:
+			errs = append(errs, fmt.Errorf("%w: %s is older than %s", ErrDowngrade, version, currentVersion))
+		case version.EQ(currentVersion):
+			errs = append(errs, fmt.Errorf("%w: %s", ErrSameVersion, version))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// completeRange adds the missing minor and patch to the versions of a range, so >=1.4 <2 becomes >=1.4.0 <2.0.0.
+func completeRange(constraint string) string {
+	parts := strings.Fields(constraint)
+	for i, part := range parts {
+		matches := partialVersionRegex.FindStringSubmatch(part)
+		if matches == nil {
+			continue
+		}
+		if matches[3] == "" {
+			parts[i] = matches[1] + matches[2] + ".0.0"
+		} else {
+			parts[i] = matches[1] + matches[2] + matches[3] + ".0"
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,140 @@
+package policy
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+)
+
+func TestVersionPolicy(t *testing.T) {
+	greenCluster := model.ClusterState{
+		model.Blue: model.ClusterGroupState{},
+		model.Green: model.ClusterGroupState{
+			Count:   3,
+			Version: semver.MustParse("1.5.0"),
+		},
+	}
+	tests := []struct {
+		name         string
+		config       VersionConfig
+		current      model.ClusterState
+		version      semver.Version
+		expectedErrs []error
+	}{
+		{
+			name:    "empty_cluster",
+			current: model.NewClusterState(),
+			version: semver.MustParse("0.0.1"),
+		},
+		{
+			name:    "upgrade",
+			current: greenCluster,
+			version: semver.MustParse("1.6.0"),
+		},
+		{
+			name:         "downgrade",
+			current:      greenCluster,
+			version:      semver.MustParse("1.4.9"),
+			expectedErrs: []error{ErrDowngrade},
+		},
+		{
+			name:    "allowed_downgrade",
+			config:  VersionConfig{AllowDowngrade: true},
+			current: greenCluster,
+			version: semver.MustParse("1.4.9"),
+		},
+		{
+			name:         "same_version",
+			config:       VersionConfig{AllowDowngrade: true},
+			current:      greenCluster,
+			version:      semver.MustParse("1.5.0"),
+			expectedErrs: []error{ErrSameVersion},
+		},
+		{
+			name:    "prerelease",
+			current: greenCluster,
+			version: semver.MustParse("1.6.0-rc1"),
+		},
+		{
+			name:         "rejected_prerelease",
+			config:       VersionConfig{RejectPrerelease: true},
+			current:      greenCluster,
+			version:      semver.MustParse("1.6.0-rc1"),
+			expectedErrs: []error{ErrPrerelease},
+		},
+		{
+			name:         "rejected_build_metadata",
+			config:       VersionConfig{RejectBuildMetadata: true},
+			current:      greenCluster,
+			version:      semver.MustParse("1.6.0+build.5"),
+			expectedErrs: []error{ErrBuildMetadata},
+		},
+		{
+			name:    "in_constraint",
+			config:  VersionConfig{Constraint: ">=1.4 <2"},
+			current: greenCluster,
+			version: semver.MustParse("1.9.3"),
+		},
+		{
+			name:         "not_in_constraint",
+			config:       VersionConfig{Constraint: ">=1.4 <2", RejectPrerelease: true},
+			current:      greenCluster,
+			version:      semver.MustParse("2.1.0-beta"),
+			expectedErrs: []error{ErrPrerelease, ErrNotInConstraint},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			policy, err := NewVersionPolicy(test.config)
+			assert.NoError(err)
+
+			err = policy.Check(test.current, test.version)
+			if len(test.expectedErrs) == 0 {
+				assert.NoError(err)
+				return
+			}
+			var multiErr model.MultiError
+			if assert.True(errors.As(err, &multiErr)) && assert.Len(multiErr.Errors(), len(test.expectedErrs)) {
+				for i, expectedErr := range test.expectedErrs {
+					assert.True(errors.Is(multiErr.Errors()[i], expectedErr), multiErr.Errors()[i].Error())
+				}
+			}
+		})
+	}
+}
+
+func TestVersionPolicyBeforeCluster(t *testing.T) {
+	assert := assert.New(t)
+	policy, err := NewVersionPolicy(VersionConfig{RejectPrerelease: true, Constraint: ">=1.4 <2"})
+	assert.NoError(err)
+	greenCluster := model.ClusterState{
+		model.Blue:  model.ClusterGroupState{},
+		model.Green: model.ClusterGroupState{Count: 3, Version: semver.MustParse("1.5.0")},
+	}
+	version := semver.MustParse("1.4.1-rc1")
+
+	// the version is checked without the cluster, the downgrade only with it.
+	err = policy.CheckVersion(version)
+	assert.ErrorIs(err.(model.Errors)[0], ErrPrerelease)
+	assert.Len(err.(model.Errors), 1)
+	err = policy.CheckCurrent(greenCluster, version)
+	assert.ErrorIs(err.(model.Errors)[0], ErrDowngrade)
+	assert.Len(err.(model.Errors), 1)
+	assert.NoError(policy.CheckVersion(semver.MustParse("1.5.0")))
+	assert.NoError(policy.CheckCurrent(model.NewClusterState(), version))
+}
+
+func TestInvalidConstraint(t *testing.T) {
+	_, err := NewVersionPolicy(VersionConfig{Constraint: "~>1.4"})
+	assert.True(t, errors.Is(err, ErrInvalidConstraint))
+}
+
+func TestCompleteRange(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(">=1.4.0 <2.0.0", completeRange(">=1.4 <2"))
+	assert.Equal(">=1.4.0 <2.0.0 || 3.1.0", completeRange(">=1.4.0 <2.0.0 || 3.1"))
+	assert.Equal("!1.2.3-rc1", completeRange("!1.2.3-rc1"))
+}
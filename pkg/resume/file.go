@@ -0,0 +1,164 @@
+package resume
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xmidt-org/carousel/pkg/model"
+	"github.com/xmidt-org/carousel/pkg/step"
+)
+
+// CurrentVersion is the version of the resume file format written by carousel.
+// Version 1 is the original model.StepError json without a version.
+const CurrentVersion = 2
+
+// TimestampFormat is used to add a timestamp to the file name, the output logs of a transition use it as well.
+const TimestampFormat = "20060102T150405Z"
+
+var (
+	ErrInvalidFile        = errors.New("invalid resume file")
+	ErrUnsupportedVersion = errors.New("unsupported resume file version")
+)
+
+// File is a self describing resume file, written when a transition fails so it can be resumed later.
+type File struct {
+	// Version is the version of the resume file format.
+	Version int `json:"version"`
+
+	// CarouselVersion is the version of carousel that wrote the file.
+	CarouselVersion string `json:"carousel_version,omitempty"`
+
+	// Workspace is the workspace the transition ran in.
+	Workspace string `json:"workspace,omitempty"`
+
+	// Config is the configuration the transition ran with. Private values should be redacted.
+	Config json.RawMessage `json:"config,omitempty"`
+
+	// StartedAt is when the transition started.
+	StartedAt time.Time `json:"started_at"`
+
+	// FailedAt is when the transition failed.
+	FailedAt time.Time `json:"failed_at"`
+
+	// Cause is the error that failed the transition.
+	Cause string `json:"cause,omitempty"`
+
+	// Output is the captured output of the failed command, if any.
+	Output string `json:"output,omitempty"`
+
+	// LogDir is the directory of the output of every command run by the transition, if kept.
+	LogDir string `json:"log_dir,omitempty"`
+
+	// Rollout is the batch size and skip first n the steps were built with, nil if the file doesn't have them.
+	Rollout *Rollout `json:"rollout,omitempty"`
+
+	TODO               []model.Step       `json:"todo"`
+	OriginalCluster    model.Cluster      `json:"original_cluster"`
+	StartingColorGroup model.Color        `json:"starting_group"`
+	GoalClusterState   model.ClusterState `json:"goal_state"`
+	// Replace are the resources the failed apply was replacing, they are replaced by the next apply on resume.
+	Replace []string `json:"replace,omitempty"`
+}
+
+// Rollout are the constraints the steps of a File were built with, including the overrides of an apply.
+// The steps are only valid for the same constraints.
+type Rollout struct {
+	BatchSize  int `json:"batch_size"`
+	SkipFirstN int `json:"skip_first_n"`
+}
+
+// StepOptions returns the step.StepOptions of the Rollout.
+func (r Rollout) StepOptions() []step.StepOptions {
+	return []step.StepOptions{
+		step.WithBatchSize(r.BatchSize),
+		step.WithSkipFirstN(r.SkipFirstN),
+	}
+}
+
+// New builds a File of the CurrentVersion from a StepError.
+// The output of a model.RunnableError cause is kept in the File.
+func New(stepError model.StepError, startedAt time.Time, failedAt time.Time) File {
+	f := File{
+		Version:            CurrentVersion,
+		StartedAt:          startedAt.UTC(),
+		FailedAt:           failedAt.UTC(),
+		TODO:               stepError.TODO,
+		OriginalCluster:    stepError.OriginalCluster,
+		StartingColorGroup: stepError.StartingColorGroup,
+		GoalClusterState:   stepError.GoalClusterState,
+		Replace:            stepError.Replace,
+	}
+	if stepError.Cause != nil {
+		f.Cause = stepError.Cause.Error()
+		var runnableErr model.RunnableError
+		if errors.As(stepError.Cause, &runnableErr) {
+			f.Output = string(runnableErr.Output)
+		}
+	}
+	return f
+}
+
+// StepError returns the StepError to resume from.
+func (f File) StepError() model.StepError {
+	var cause error
+	if f.Cause != "" {
+		cause = errors.New(f.Cause)
+	}
+	return model.StepError{
+		Cause:              cause,
+		TODO:               f.TODO,
+		OriginalCluster:    f.OriginalCluster,
+		StartingColorGroup: f.StartingColorGroup,
+		GoalClusterState:   f.GoalClusterState,
+		Replace:            f.Replace,
+	}
+}
+
+// Load reads a resume file, older versions are migrated to the CurrentVersion.
+func Load(data []byte) (File, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return File{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	switch header.Version {
+	case 0, 1:
+		return migrateV1(data)
+	case CurrentVersion:
+		var f File
+		if err := json.Unmarshal(data, &f); err != nil {
+			return File{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return f, nil
+	default:
+		return File{}, fmt.Errorf("%w: %d, newest supported is %d", ErrUnsupportedVersion, header.Version, CurrentVersion)
+	}
+}
+
+// migrateV1 reads a model.StepError json file.
+func migrateV1(data []byte) (File, error) {
+	var stepError model.StepError
+	if err := json.Unmarshal(data, &stepError); err != nil {
+		return File{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return File{
+		Version:            CurrentVersion,
+		TODO:               stepError.TODO,
+		OriginalCluster:    stepError.OriginalCluster,
+		StartingColorGroup: stepError.StartingColorGroup,
+		GoalClusterState:   stepError.GoalClusterState,
+	}, nil
+}
+
+// FileName adds a timestamp to the name, so a previous file is not overwritten.
+// err.json becomes err-20060102T150405Z.json.
+func FileName(name string, t time.Time) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), t.UTC().Format(TimestampFormat), ext)
+}
@@ -0,0 +1,164 @@
+package resume
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+const legacyFile = `{
+ "todo": [
+  {"blue": 1, "green": 1},
+  {"blue": 1, "green": 0}
+ ],
+ "original_cluster": {
+  "green": {"hosts": ["green-1"], "version": "0.1.0"}
+ },
+ "starting_group": "green",
+ "goal_state": {
+  "blue": {"count": 1, "version": "0.2.0"},
+  "green": {"count": 0, "version": "0.0.0"}
+ }
+}`
+
+func TestLoad(t *testing.T) {
+	startedAt := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	current := New(model.StepError{
+		Cause: model.RunnableError{
+			Output:    []byte("terraform failed"),
+			ResultErr: errors.New("exit status 1"),
+		},
+		TODO:               []model.Step{{model.Blue: 1, model.Green: 0}},
+		StartingColorGroup: model.Green,
+		GoalClusterState: model.ClusterState{
+			model.Blue: model.ClusterGroupState{Count: 1, Version: semver.MustParse("0.2.0")},
+		},
+	}, startedAt, startedAt.Add(time.Minute))
+	current.CarouselVersion = "1.2.3"
+	current.Workspace = "dev"
+	current.Config = json.RawMessage(`{"Workspace":"dev"}`)
+	currentData, _ := json.Marshal(current)
+
+	tests := []struct {
+		name        string
+		data        string
+		expected    File
+		expectedErr error
+	}{
+		{
+			name: "legacy",
+			data: legacyFile,
+			expected: File{
+				Version: CurrentVersion,
+				TODO: []model.Step{
+					{model.Blue: 1, model.Green: 1},
+					{model.Blue: 1, model.Green: 0},
+				},
+				OriginalCluster: model.Cluster{
+					model.Green: model.ClusterGroup{Hosts: []string{"green-1"}, Version: semver.MustParse("0.1.0")},
+				},
+				StartingColorGroup: model.Green,
+				GoalClusterState: model.ClusterState{
+					model.Blue:  model.ClusterGroupState{Count: 1, Version: semver.MustParse("0.2.0")},
+					model.Green: model.ClusterGroupState{Count: 0, Version: semver.MustParse("0.0.0")},
+				},
+			},
+		},
+		{
+			name:     "current",
+			data:     string(currentData),
+			expected: current,
+		},
+		{
+			name:        "newer_version",
+			data:        fmt.Sprintf(`{"version": %d}`, CurrentVersion+1),
+			expectedErr: ErrUnsupportedVersion,
+		},
+		{
+			name:        "invalid_json",
+			data:        `{"todo": [`,
+			expectedErr: ErrInvalidFile,
+		},
+		{
+			name:        "invalid_color",
+			data:        `{"version": 2, "starting_group": "purple"}`,
+			expectedErr: ErrInvalidFile,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			file, err := Load([]byte(test.data))
+			if test.expectedErr != nil {
+				assert.ErrorIs(err, test.expectedErr)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.expected, file)
+		})
+	}
+}
+
+func TestLoadConfiguredGroups(t *testing.T) {
+	assert := assert.New(t)
+	defer model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}})
+	assert.NoError(model.SetColorGroups([]model.ColorGroup{{Name: "red"}, {Name: "canary", Pinned: true}, {Name: "blue"}}))
+
+	file, err := Load([]byte(`{
+ "version": 2,
+ "todo": [{"red": 1, "canary": 1, "blue": 2}, {"red": 2, "canary": 1, "blue": 2}],
+ "starting_group": "blue",
+ "goal_state": {"red": {"count": 2, "version": "0.2.0"}, "canary": {"count": 1, "version": "0.1.0"}}
+}`))
+	assert.NoError(err)
+	assert.Equal(model.Color("blue"), file.StartingColorGroup)
+	assert.Equal([]model.Step{
+		{model.Color("red"): 1, model.Color("canary"): 1, model.Blue: 2},
+		{model.Color("red"): 2, model.Color("canary"): 1, model.Blue: 2},
+	}, file.TODO)
+	assert.Equal(2, file.GoalClusterState[model.Color("red")].Count)
+}
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+	startedAt := time.Date(2021, 3, 4, 5, 6, 7, 0, time.FixedZone("EST", -5*60*60))
+	file := New(model.StepError{
+		Cause: fmt.Errorf("apply failed: %w", model.RunnableError{
+			Output:    []byte("Error: quota exceeded"),
+			ResultErr: errors.New("exit status 1"),
+		}),
+		TODO: []model.Step{{model.Blue: 1}},
+	}, startedAt, startedAt.Add(time.Minute))
+
+	assert.Equal(CurrentVersion, file.Version)
+	assert.Equal("apply failed: exit status 1", file.Cause)
+	assert.Equal("Error: quota exceeded", file.Output)
+	assert.Equal(time.UTC, file.StartedAt.Location())
+	assert.Equal(startedAt.Add(time.Minute).UTC(), file.FailedAt)
+	assert.Equal([]model.Step{{model.Blue: 1}}, file.StepError().TODO)
+	assert.EqualError(file.StepError(), "apply failed: exit status 1")
+}
+
+func TestFileName(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "err.json", expected: "err-20210304T050607Z.json"},
+		{name: "out/steps.json", expected: "out/steps-20210304T050607Z.json"},
+		{name: "steps", expected: "steps-20210304T050607Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, FileName(test.name, now))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// outputLog is the OutputLog every cmdRunner writes to, if set.
+var outputLog atomic.Pointer[OutputLog]
+
+// SetOutputLog makes every Runnable built with NewCMDRunner write its output to the OutputLog, nil stops it.
+func SetOutputLog(log *OutputLog) {
+	outputLog.Store(log)
+}
+
+// OutputLog keeps the full stdout and stderr of each command run in a directory, one file per run named by the step
+// and attempt, e.g. step-02-apply-1.log. Each file starts with a header of the command, duration and exit code.
+// The command is the String of the Runnable, so private values are redacted.
+type OutputLog struct {
+	dir      string
+	lock     sync.Mutex
+	step     int
+	attempts map[string]int
+}
+
+// NewOutputLog builds an OutputLog, the directory is created on the first write.
+func NewOutputLog(dir string) *OutputLog {
+	return &OutputLog{dir: dir, attempts: map[string]int{}}
+}
+
+// Dir returns the directory of the log files.
+func (l *OutputLog) Dir() string {
+	return l.dir
+}
+
+// Step sets the step of the next commands, starting at 1. Commands run before the first step are step 0.
+func (l *OutputLog) Step(step int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.step = step
+}
+
+// write writes the output of a run of the command, the name is the kind of command like apply.
+func (l *OutputLog) write(name string, command string, started time.Time, duration time.Duration, exitCode int, stdout []byte, stderr []byte) (string, error) {
+	l.lock.Lock()
+	key := fmt.Sprintf("step-%02d-%s", l.step, name)
+	l.attempts[key]++
+	path := filepath.Join(l.dir, fmt.Sprintf("%s-%d.log", key, l.attempts[key]))
+	l.lock.Unlock()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# command: %s\n", command)
+	fmt.Fprintf(&buf, "# started: %s\n", started.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "# duration: %s\n", duration.Round(time.Millisecond))
+	fmt.Fprintf(&buf, "# exit code: %d\n", exitCode)
+	fmt.Fprintf(&buf, "\n## stdout\n%s", stdout)
+	fmt.Fprintf(&buf, "\n## stderr\n%s", stderr)
+
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return path, err
+	}
+	return path, os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// logName returns the kind of command of the args, the first argument that isn't a flag.
+func logName(binary string, args []string) string {
+	name := filepath.Base(binary)
+	for _, arg := range args {
+		if arg != "" && !strings.HasPrefix(arg, "-") {
+			name = arg
+			break
+		}
+	}
+	return sanitizeLogName(name)
+}
+
+// sanitizeLogName keeps the letters, digits, underscores and dashes of a name so it can be part of a file name.
+func sanitizeLogName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+		if b.Len() >= 32 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "command"
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputLog(t *testing.T) {
+	assert := assert.New(t)
+	dir := filepath.Join(t.TempDir(), "logs")
+	log := NewOutputLog(dir)
+	SetOutputLog(log)
+	t.Cleanup(func() { SetOutputLog(nil) })
+
+	options := Options{}.WithSuppressErrOutput(true)
+	state := NewCMDRunner("", "sh", options, "-c", "echo state")
+	apply := AddEnvironment(NewCMDRunner("", "sh", options, "-c", "echo applying; echo failed >&2; exit 3"), "TF_VAR_", []model.ValuePair{{Key: "secret", Value: "hunter2"}})
+	apply = WithLogName(apply, "apply")
+
+	_, err := state.Output()
+	require.NoError(t, err)
+	log.Step(1)
+	_, err = apply.Output()
+	assert.Error(err)
+	_, err = apply.Output()
+	assert.Error(err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	require.NoError(t, err)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.Equal([]string{"step-00-echo-state-1.log", "step-01-apply-1.log", "step-01-apply-2.log"}, files)
+
+	data, err := os.ReadFile(filepath.Join(dir, "step-01-apply-2.log"))
+	require.NoError(t, err)
+	content := string(data)
+	assert.True(strings.HasPrefix(content, "# command: TF_VAR_secret=xxxx sh -c"), content)
+	assert.NotContains(content, "hunter2")
+	assert.Contains(content, "# exit code: 3\n")
+	assert.Contains(content, "## stdout\napplying\n")
+	assert.Contains(content, "## stderr\nfailed\n")
+}
+
+func TestOutputLogRedactsFlagValues(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	log := NewOutputLog(dir)
+	SetOutputLog(log)
+	t.Cleanup(func() { SetOutputLog(nil) })
+
+	init := NewCMDRunner("", "sh", Options{}, "-c", "true", "-backend-config=prod.s3.tfbackend", "-backend-config=bucket=prod-state")
+	init = RedactFlagValues(init, "-backend-config=")
+	assert.Equal("sh -c true -backend-config=prod.s3.tfbackend -backend-config=bucket=xxxx", init.String())
+	_, err := init.Output()
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(dir, "step-00-true-1.log"))
+	require.NoError(t, err)
+	content := string(data)
+	assert.True(strings.HasPrefix(content, "# command: sh -c true -backend-config=prod.s3.tfbackend -backend-config=bucket=xxxx\n"), content)
+	assert.NotContains(content, "prod-state")
+}
+
+func TestLogName(t *testing.T) {
+	tests := []struct {
+		name     string
+		binary   string
+		args     []string
+		expected string
+	}{
+		{name: "subcommand", binary: "terraform", args: []string{"apply", "-auto-approve"}, expected: "apply"},
+		{name: "flags_first", binary: "terraform", args: []string{"-chdir=infra", "state", "pull"}, expected: "state"},
+		{name: "no_args", binary: "/usr/local/bin/cluster.sh", expected: "cluster-sh"},
+		{name: "unsafe", binary: "sh", args: []string{"../../etc"}, expected: "------etc"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, logName(test.binary, test.args))
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 )
 
 var (
@@ -32,6 +33,8 @@ type cmdRunner struct {
 	commandString string
 	cmd           exec.Cmd
 	options       Options
+	// logName is the kind of command in the name of its OutputLog files.
+	logName string
 }
 
 func (c *cmdRunner) String() string {
@@ -59,8 +62,10 @@ func (c *cmdRunner) Output() ([]byte, error) {
 	copyCMD.Stdout = outWriter
 	copyCMD.Stderr = errWriter
 
+	started := time.Now()
 	if err := copyCMD.Start(); err != nil {
 		// bad path, binary not executable, &c
+		c.log(started, -1, nil, []byte(err.Error()))
 		return nil, err
 	}
 
@@ -87,15 +92,29 @@ func (c *cmdRunner) Output() ([]byte, error) {
 	cmdChannel <- err
 
 	if err != nil {
-		return stdOutBuf.Bytes(), ExitError{
+		exitErr := ExitError{
 			CapturedError:       err,
 			CapturedErrorOutput: stdErrBuf.Bytes(),
 		}
+		c.log(started, exitErr.GetCode(), stdOutBuf.Bytes(), stdErrBuf.Bytes())
+		return stdOutBuf.Bytes(), exitErr
 	}
 
+	c.log(started, 0, stdOutBuf.Bytes(), stdErrBuf.Bytes())
 	return stdOutBuf.Bytes(), nil
 }
 
+// log writes the output of a run to the OutputLog, if set. A failure to write is reported without failing the run.
+func (c *cmdRunner) log(started time.Time, exitCode int, stdout []byte, stderr []byte) {
+	log := outputLog.Load()
+	if log == nil {
+		return
+	}
+	if path, err := log.write(c.logName, c.commandString, started, time.Since(started), exitCode, stdout, stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write output log %s: %v\n", path, err)
+	}
+}
+
 type Options struct {
 	// Whether to attach stdin configuration. assume false
 	Interactive bool
@@ -145,6 +164,7 @@ func NewCMDRunner(dir, binary string, options Options, args ...string) Runnable
 
 	return &cmdRunner{
 		commandString: strings.Join(append([]string{binary}, args...), " "),
+		logName:       logName(binary, args),
 		cmd:           *cmd,
 		options:       options,
 	}
@@ -155,7 +175,7 @@ func NewCMDRunner(dir, binary string, options Options, args ...string) Runnable
 func AddEnvironment(runner Runnable, prefix string, environment []model.ValuePair) Runnable {
 	if cRun, ok := runner.(*cmdRunner); ok {
 		for _, pair := range environment {
-			cRun.commandString = fmt.Sprintf("%s%s=xxxx", prefix, pair.Key) + cRun.commandString
+			cRun.commandString = fmt.Sprintf("%s%s=xxxx %s", prefix, pair.Key, cRun.commandString)
 			cRun.cmd.Env = append(cRun.cmd.Env, fmt.Sprintf("%s%s=%s", prefix, pair.Key, pair.Value))
 		}
 		return cRun
@@ -163,6 +183,47 @@ func AddEnvironment(runner Runnable, prefix string, environment []model.ValuePai
 	return runner
 }
 
+// AddVisibleEnvironment adds environment variables to a given Runnable like AddEnvironment, but the values are part of
+// the command String instead of being hidden, e.g. CAROUSEL_BLUE_COUNT=2 ./cluster.sh apply.
+func AddVisibleEnvironment(runner Runnable, prefix string, environment []model.ValuePair) Runnable {
+	if cRun, ok := runner.(*cmdRunner); ok {
+		for i := len(environment) - 1; i >= 0; i-- {
+			pair := environment[i]
+			cRun.commandString = fmt.Sprintf("%s%s=%s %s", prefix, pair.Key, pair.Value, cRun.commandString)
+			cRun.cmd.Env = append(cRun.cmd.Env, fmt.Sprintf("%s%s=%s", prefix, pair.Key, pair.Value))
+		}
+		return cRun
+	}
+	return runner
+}
+
+// RedactFlagValues hides the values of the key=value flags with the given prefix in the command String of a given
+// Runnable, like AddEnvironment hides the environment, e.g. -backend-config=bucket=xxxx. A flag without a key, e.g. a
+// file, is kept. This will only do something if the runnable was built with NewCMDRunner.
+func RedactFlagValues(runner Runnable, prefix string) Runnable {
+	if cRun, ok := runner.(*cmdRunner); ok {
+		for _, arg := range cRun.cmd.Args[1:] {
+			key, _, found := strings.Cut(strings.TrimPrefix(arg, prefix), "=")
+			if !strings.HasPrefix(arg, prefix) || !found {
+				continue
+			}
+			cRun.commandString = strings.Replace(cRun.commandString, " "+arg, fmt.Sprintf(" %s%s=xxxx", prefix, key), 1)
+		}
+		return cRun
+	}
+	return runner
+}
+
+// WithLogName names the OutputLog files of a given Runnable, instead of the first argument that isn't a flag.
+// This will only do something if the runnable was built with NewCMDRunner.
+func WithLogName(runner Runnable, name string) Runnable {
+	if cRun, ok := runner.(*cmdRunner); ok {
+		cRun.logName = sanitizeLogName(name)
+		return cRun
+	}
+	return runner
+}
+
 type ExitError struct {
 	CapturedError       error
 	CapturedErrorOutput []byte
@@ -0,0 +1,18 @@
+package runner
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+)
+
+func TestAddEnvironment(t *testing.T) {
+	assert := assert.New(t)
+	r := NewCMDRunner("", "terraform", Options{}, "apply")
+	r = AddEnvironment(r, "TF_VAR_", []model.ValuePair{{Key: "token", Value: "hunter2"}})
+	r = AddEnvironment(r, "", []model.ValuePair{{Key: "AWS_PROFILE", Value: "prod"}})
+	assert.Equal("AWS_PROFILE=xxxx TF_VAR_token=xxxx terraform apply", r.String())
+	cRun := r.(*cmdRunner)
+	assert.Contains(cRun.cmd.Env, "TF_VAR_token=hunter2")
+	assert.Contains(cRun.cmd.Env, "AWS_PROFILE=prod")
+}
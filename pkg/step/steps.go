@@ -22,6 +22,7 @@ func WithBatchSize(size int) StepOptions {
 	return func(o *options) {
 		if size <= 0 {
 			o.batchSize = 1
+			return
 		}
 		o.batchSize = size
 	}
@@ -34,12 +35,25 @@ func WithSkipFirstN(n int) StepOptions {
 	return func(o *options) {
 		if n < 0 {
 			o.skipFirstN = 0
+			return
 		}
 		o.skipFirstN = n
 	}
 }
 
-// CreateSteps will generate the Blue, Green steps to switch from a current ClusterState to a target ClusterState.
+// buildOptions applies the StepOptions on top of the default options.
+func buildOptions(stepOptions ...StepOptions) *options {
+	o := &options{
+		batchSize:  1,
+		skipFirstN: 0,
+	}
+	for _, updateFunc := range stepOptions {
+		updateFunc(o)
+	}
+	return o
+}
+
+// CreateSteps will generate the steps to switch from a current ClusterState to a target ClusterState.
 //
 // Starting point for rollback
 //
@@ -55,66 +69,54 @@ func CreateSteps(currentCluster model.ClusterState, targetCluster model.ClusterS
 	if currentCluster.IsEmpty() && targetCluster.IsEmpty() {
 		return []model.Step{AsStep(targetCluster)}
 	}
-	options := &options{
-		batchSize:  1,
-		skipFirstN: 0,
-	}
-	for _, updateFunc := range stepOptions {
-		updateFunc(options)
-	}
+	options := buildOptions(stepOptions...)
 
-	buildColor, _ := targetCluster.Group()
-	if buildColor == model.Unknown {
-		buildColor = model.ValidColors[0]
-	}
-	return append([]model.Step{AsStep(currentCluster)}, generateSteps(currentCluster, targetCluster, options, buildColor, true, []model.Step{})...)
+	return append([]model.Step{AsStep(currentCluster)}, generateSteps(currentCluster, targetCluster, options, true, []model.Step{})...)
 }
 
 // generateSteps is a tail recursive call for building steps with the create step prepended to the list.
-func generateSteps(currentCluster model.ClusterState, targetCluster model.ClusterState, options *options, group model.Color, addNodes bool, steps []model.Step) []model.Step {
+// Each call alternates between adding nodes to a growing Color Group and removing nodes from a shrinking one,
+// falling back to the other when there is nothing left to add or remove.
+func generateSteps(currentCluster model.ClusterState, targetCluster model.ClusterState, options *options, addNodes bool, steps []model.Step) []model.Step {
 	// BaseCase
 	if currentCluster.EqualNodeCount(targetCluster) {
 		return steps
 	}
-	var nextState model.ClusterState
 
-	var (
-		currentNodeCount = currentCluster[group].Count
-		targetNodeCount  = targetCluster[group].Count
-	)
-	if currentCluster[group].Count < targetCluster[group].Count && addNodes {
-		nextState = currentCluster.AddNodes(group, addAndSkip(currentNodeCount, targetNodeCount, options.batchSize, options.skipFirstN))
-	} else if currentCluster[group].Count > targetCluster[group].Count && !addNodes {
-		nextState = currentCluster.AddNodes(group, -minusAndSkip(currentNodeCount, targetNodeCount, options.batchSize, options.skipFirstN))
-	} else { // Can't add or remove nodes in group anymore
-		var (
-			otherCurrentNodeCount = currentCluster[group.Other()].Count
-			otherTargetNodeCount  = targetCluster[group.Other()].Count
-		)
-		if currentCluster[group.Other()].Count < targetCluster[group.Other()].Count {
-			nextState = currentCluster.AddNodes(group.Other(), addAndSkip(otherCurrentNodeCount, otherTargetNodeCount, options.batchSize, options.skipFirstN))
-		} else if currentCluster[group.Other()].Count > targetCluster[group.Other()].Count {
-			nextState = currentCluster.AddNodes(group.Other(), -minusAndSkip(otherCurrentNodeCount, otherTargetNodeCount, options.batchSize, options.skipFirstN))
-		} else if currentCluster[group].Count < targetCluster[group].Count {
-			nextState = currentCluster.AddNodes(group, addAndSkip(currentNodeCount, targetNodeCount, options.batchSize, options.skipFirstN))
-		} else if currentCluster[group].Count > targetCluster[group].Count {
-			nextState = currentCluster.AddNodes(group, -minusAndSkip(currentNodeCount, targetNodeCount, options.batchSize, options.skipFirstN))
-		} else {
-			panic("next state not created")
+	growing, shrinking := model.Unknown, model.Unknown
+	for _, color := range model.ValidColors {
+		if growing == model.Unknown && currentCluster[color].Count < targetCluster[color].Count {
+			growing = color
+		}
+		if shrinking == model.Unknown && currentCluster[color].Count > targetCluster[color].Count {
+			shrinking = color
 		}
 	}
 
-	return append([]model.Step{AsStep(nextState)}, generateSteps(nextState, targetCluster, options, group.Other(), !addNodes, steps)...)
+	var nextState model.ClusterState
+	switch {
+	case growing != model.Unknown && (addNodes || shrinking == model.Unknown):
+		nextState = currentCluster.AddNodes(growing, addAndSkip(currentCluster[growing].Count, targetCluster[growing].Count, options.batchSize, options.skipFirstN))
+	case shrinking != model.Unknown:
+		nextState = currentCluster.AddNodes(shrinking, -minusAndSkip(currentCluster[shrinking].Count, targetCluster[shrinking].Count, options.batchSize, options.skipFirstN))
+	default:
+		panic("next state not created")
+	}
+
+	return append([]model.Step{AsStep(nextState)}, generateSteps(nextState, targetCluster, options, !addNodes, steps)...)
 }
 
+// addAndSkip returns the number of nodes to add to a group, jumping past the first skipfirstN nodes
+// without ever going above the targetNodeCount.
 func addAndSkip(currentNodeCount int, targetNodeCount int, batchSize int, skipfirstN int) int {
-	if currentNodeCount > skipfirstN && currentNodeCount+batchSize >= targetNodeCount {
+	add := batchSize
+	if currentNodeCount+batchSize <= skipfirstN {
+		add = skipfirstN + 1 - currentNodeCount
+	}
+	if currentNodeCount+add > targetNodeCount {
 		return targetNodeCount - currentNodeCount
-	} else if currentNodeCount+batchSize <= skipfirstN {
-		return skipfirstN + 1
-	} else { // currentNodeCount > skipfirstN  && currentNodeCount + batchSize < targetNodeCount
-		return batchSize
 	}
+	return add
 }
 
 func minusAndSkip(currentNodeCount int, targetNodeCount int, batchSize int, skipfirstN int) int {
@@ -179,6 +179,32 @@ func TestCreateSteps(t *testing.T) {
 				{model.Green: 0, model.Blue: 0},
 			},
 		},
+		{
+			name: "target_smaller_than_batch",
+			sourceCluster: model.ClusterState{
+				model.Green: model.ClusterGroupState{
+					Count:   5,
+					Version: semver.Version{},
+				},
+				model.Blue: model.ClusterGroupState{},
+			},
+			targetCluster: model.ClusterState{
+				model.Blue: model.ClusterGroupState{
+					Count:   1,
+					Version: semver.Version{},
+				},
+				model.Green: model.ClusterGroupState{},
+			},
+			options: []StepOptions{
+				WithBatchSize(3),
+			},
+			expectedSteps: []model.Step{
+				{model.Blue: 0, model.Green: 5},
+				{model.Blue: 1, model.Green: 5},
+				{model.Blue: 1, model.Green: 2},
+				{model.Blue: 1, model.Green: 0},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -189,3 +215,35 @@ func TestCreateSteps(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateStepsWithGroups(t *testing.T) {
+	defer model.SetColorGroups([]model.ColorGroup{{Name: "blue"}, {Name: "green"}})
+	red := model.Color("red")
+	canary := model.Color("canary")
+	if err := model.SetColorGroups([]model.ColorGroup{{Name: "red"}, {Name: "blue"}, {Name: "green"}, {Name: "canary", Pinned: true}}); err != nil {
+		t.Fatal(err)
+	}
+	assert := assert.New(t)
+	sourceCluster := model.ClusterState{
+		red:         model.ClusterGroupState{},
+		model.Blue:  model.ClusterGroupState{},
+		model.Green: model.ClusterGroupState{Count: 2},
+		canary:      model.ClusterGroupState{Count: 1},
+	}
+	targetCluster := model.ClusterState{
+		red:         model.ClusterGroupState{Count: 2},
+		model.Blue:  model.ClusterGroupState{},
+		model.Green: model.ClusterGroupState{},
+		canary:      model.ClusterGroupState{Count: 1},
+	}
+	expectedSteps := []model.Step{
+		{red: 0, model.Blue: 0, model.Green: 2, canary: 1},
+		{red: 1, model.Blue: 0, model.Green: 2, canary: 1},
+		{red: 1, model.Blue: 0, model.Green: 1, canary: 1},
+		{red: 2, model.Blue: 0, model.Green: 1, canary: 1},
+		{red: 2, model.Blue: 0, model.Green: 0, canary: 1},
+	}
+	actualSteps := CreateSteps(sourceCluster, targetCluster)
+	assert.Equal(expectedSteps, actualSteps)
+	assert.NoError(Validate(actualSteps, targetCluster))
+}
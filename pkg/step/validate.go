@@ -0,0 +1,112 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xmidt-org/carousel/pkg/model"
+)
+
+var (
+	ErrNoSteps           = errors.New("no steps to validate")
+	ErrBelowMinCapacity  = errors.New("total capacity below minimum")
+	ErrBatchSizeExceeded = errors.New("group changed by more than the batch size")
+	ErrGroupAboveTarget  = errors.New("group above target")
+	ErrNotMonotonic      = errors.New("group does not progress towards target")
+)
+
+// Validate checks that a sequence of Steps could have been built by CreateSteps with the same StepOptions
+// to reach the target ClusterState, starting at the first Step.
+//
+// The following constraints are checked:
+//   - the total node count never drops below the smaller of the starting and target total, less the nodes that
+//     can be dropped at once when a group is emptied out of the skipped first N nodes.
+//   - a group never changes by more than the batch size, unless it is moving in or out of the skipped first N nodes.
+//   - a group never goes above its target if growing, or above its starting count if shrinking.
+//   - each group only moves towards its target, a repeated step is allowed.
+//
+// All violations are returned as model.Errors.
+func Validate(steps []model.Step, targetCluster model.ClusterState, stepOptions ...StepOptions) error {
+	if len(steps) == 0 {
+		return ErrNoSteps
+	}
+	options := buildOptions(stepOptions...)
+
+	var (
+		first       = steps[0]
+		target      = AsStep(targetCluster)
+		minCapacity = minCapacity(first, target, options)
+		errs        model.Errors
+	)
+	for index := 1; index < len(steps); index++ {
+		prev, curr := steps[index-1], steps[index]
+		if total(curr) < minCapacity {
+			errs = append(errs, fmt.Errorf("%w: step %d has %d nodes, minimum is %d", ErrBelowMinCapacity, index, total(curr), minCapacity))
+		}
+		for color, count := range unionCounts(prev, curr, target) {
+			from, to := count[0], count[1]
+			if diff := abs(to - from); diff > options.batchSize {
+				if min(from, to) > options.skipFirstN || max(from, to) > options.skipFirstN+options.batchSize {
+					errs = append(errs, fmt.Errorf("%w: step %d changes %s by %d", ErrBatchSizeExceeded, index, color, diff))
+				}
+			}
+			if limit := max(first[color], target[color]); to > limit {
+				errs = append(errs, fmt.Errorf("%w: step %d has %s at %d, limit is %d", ErrGroupAboveTarget, index, color, to, limit))
+			}
+			if abs(target[color]-to) > abs(target[color]-from) || (to-target[color])*(from-target[color]) < 0 {
+				errs = append(errs, fmt.Errorf("%w: step %d moves %s from %d to %d with a target of %d", ErrNotMonotonic, index, color, from, to, target[color]))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// minCapacity returns the lowest total node count allowed between the first and target Step.
+// When a shrinking group can't stay above skipFirstN it is emptied in one step, which can leave
+// the cluster short by up to batchSize-1 nodes, but never by more than skipFirstN.
+func minCapacity(first model.Step, target model.Step, o *options) int {
+	return min(total(first), total(target)) - min(o.batchSize-1, o.skipFirstN)
+}
+
+// unionCounts returns the previous and current counts for every Color Group found in any of the given steps.
+func unionCounts(prev model.Step, curr model.Step, target model.Step) map[model.Color][2]int {
+	counts := map[model.Color][2]int{}
+	for _, s := range []model.Step{prev, curr, target} {
+		for color := range s {
+			counts[color] = [2]int{prev[color], curr[color]}
+		}
+	}
+	return counts
+}
+
+func total(s model.Step) int {
+	sum := 0
+	for _, count := range s {
+		sum += count
+	}
+	return sum
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
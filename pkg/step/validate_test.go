@@ -0,0 +1,178 @@
+package step
+
+import (
+	"errors"
+	"github.com/blang/semver/v4"
+	"github.com/xmidt-org/carousel/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	blueTarget := func(count int) model.ClusterState {
+		return model.ClusterState{
+			model.Blue: model.ClusterGroupState{
+				Count:   count,
+				Version: semver.MustParse("0.2.0"),
+			},
+			model.Green: model.ClusterGroupState{},
+		}
+	}
+	tests := []struct {
+		name          string
+		steps         []model.Step
+		targetCluster model.ClusterState
+		options       []StepOptions
+		expectedErrs  []error
+	}{
+		{
+			name:          "no_steps",
+			steps:         []model.Step{},
+			targetCluster: blueTarget(3),
+			expectedErrs:  []error{ErrNoSteps},
+		},
+		{
+			name: "empty_cluster",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 0},
+			},
+			targetCluster: model.NewClusterState(),
+		},
+		{
+			name: "generated",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 3},
+				{model.Blue: 1, model.Green: 3},
+				{model.Blue: 1, model.Green: 2},
+				{model.Blue: 2, model.Green: 2},
+				{model.Blue: 2, model.Green: 1},
+				{model.Blue: 3, model.Green: 1},
+				{model.Blue: 3, model.Green: 0},
+			},
+			targetCluster: blueTarget(3),
+		},
+		{
+			name: "skip_first_n",
+			steps: []model.Step{
+				{model.Green: 0, model.Blue: 4},
+				{model.Green: 0, model.Blue: 3},
+				{model.Green: 0, model.Blue: 0},
+			},
+			targetCluster: model.NewClusterState(),
+			options: []StepOptions{
+				WithSkipFirstN(2),
+			},
+		},
+		{
+			name: "below_min_capacity",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 3},
+				{model.Blue: 0, model.Green: 0},
+				{model.Blue: 3, model.Green: 0},
+			},
+			targetCluster: blueTarget(3),
+			options: []StepOptions{
+				WithBatchSize(3),
+			},
+			expectedErrs: []error{ErrBelowMinCapacity},
+		},
+		{
+			name: "batch_size_exceeded",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 3},
+				{model.Blue: 3, model.Green: 3},
+				{model.Blue: 3, model.Green: 0},
+			},
+			targetCluster: blueTarget(3),
+			expectedErrs:  []error{ErrBatchSizeExceeded},
+		},
+		{
+			name: "above_target",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 2},
+				{model.Blue: 1, model.Green: 2},
+				{model.Blue: 2, model.Green: 2},
+				{model.Blue: 2, model.Green: 1},
+				{model.Blue: 2, model.Green: 0},
+				{model.Blue: 1, model.Green: 0},
+			},
+			targetCluster: blueTarget(1),
+			expectedErrs:  []error{ErrGroupAboveTarget, ErrNotMonotonic},
+		},
+		{
+			name: "not_monotonic",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 2},
+				{model.Blue: 1, model.Green: 2},
+				{model.Blue: 1, model.Green: 1},
+				{model.Blue: 1, model.Green: 2},
+				{model.Blue: 2, model.Green: 2},
+				{model.Blue: 2, model.Green: 1},
+				{model.Blue: 2, model.Green: 0},
+			},
+			targetCluster: blueTarget(2),
+			expectedErrs:  []error{ErrNotMonotonic},
+		},
+		{
+			name: "repeated_step",
+			steps: []model.Step{
+				{model.Blue: 0, model.Green: 1},
+				{model.Blue: 1, model.Green: 1},
+				{model.Blue: 1, model.Green: 1},
+				{model.Blue: 1, model.Green: 0},
+			},
+			targetCluster: blueTarget(1),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+			err := Validate(test.steps, test.targetCluster, test.options...)
+			if len(test.expectedErrs) == 0 {
+				assert.NoError(err)
+				return
+			}
+			assert.Error(err)
+			for _, expectedErr := range test.expectedErrs {
+				assert.True(containsErr(err, expectedErr), "expected %v in %v", expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateCreatedSteps(t *testing.T) {
+	for current := 0; current < 8; current++ {
+		for target := 0; target < 8; target++ {
+			for batchSize := 1; batchSize < 4; batchSize++ {
+				for skip := 0; skip < 3; skip++ {
+					sourceCluster := model.ClusterState{
+						model.Green: model.ClusterGroupState{Count: current},
+						model.Blue:  model.ClusterGroupState{},
+					}
+					targetCluster := model.ClusterState{
+						model.Blue:  model.ClusterGroupState{Count: target},
+						model.Green: model.ClusterGroupState{},
+					}
+					options := []StepOptions{WithBatchSize(batchSize), WithSkipFirstN(skip)}
+					steps := CreateSteps(sourceCluster, targetCluster, options...)
+					assert.NoError(t, Validate(steps, targetCluster, options...), "steps %v", steps)
+				}
+			}
+		}
+	}
+}
+
+func containsErr(err error, target error) bool {
+	var multiErr model.MultiError
+	if errors.As(err, &multiErr) {
+		for _, e := range multiErr.Errors() {
+			if errors.Is(e, target) {
+				return true
+			}
+		}
+		return false
+	}
+	return errors.Is(err, target)
+}